@@ -0,0 +1,118 @@
+// alerts.go
+// The "get_alerts" tool.
+//
+// get_alerts returns the active weather warnings (storms, heat advisories,
+// ...) for a location from the backend's /alerts endpoint. No alerts is a
+// successful, empty answer rather than an error.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newAlertsTool defines the "get_alerts" tool.
+func newAlertsTool() mcp.Tool {
+	return mcp.NewTool("get_alerts",
+		readOnlyAnnotation("Weather Alerts", true),
+		mcp.WithDescription("Get the active weather alerts and warnings for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get alerts for"),
+		),
+		langOption(),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// weatherAlert is a single alert from the backend's /alerts response.
+type weatherAlert struct {
+	Title    string     `json:"title"`
+	Severity string     `json:"severity,omitempty"`
+	Start    *time.Time `json:"start,omitempty"`
+	End      *time.Time `json:"end,omitempty"`
+}
+
+// alertsResponse is the backend's /alerts response.
+type alertsResponse struct {
+	Location string         `json:"location"`
+	Alerts   []weatherAlert `json:"alerts"`
+	// Stale is set when the alerts came from the cache during a backend outage.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// alertsHandler handles incoming requests to the "get_alerts" tool.
+func alertsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[alertsHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	label := query.Label
+	lang, err := langArg(request)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	query.setParams(params)
+	setLangParam(params, lang)
+	backend, err := fetchBackend(ctx, "/alerts", params)
+	if err != nil {
+		return nil, err
+	}
+	var resp alertsResponse
+	if err := json.Unmarshal(backend.Body, &resp); err != nil {
+		return nil, invalidResponsef("failed to parse alerts response: %w", err)
+	}
+	if resp.Alerts == nil {
+		resp.Alerts = []weatherAlert{}
+	}
+	resp.Location = location
+	resp.Stale = backend.Stale
+
+	if len(resp.Alerts) == 0 {
+		return newStructuredResult(fmt.Sprintf("No active weather alerts for %s.", label)+backend.staleNote(), resp, prettyArg(request))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Active weather alerts for %s:", label)
+	for _, alert := range resp.Alerts {
+		fmt.Fprintf(&b, "\n- %s", alert.Title)
+		if alert.Severity != "" {
+			fmt.Fprintf(&b, " [%s]", alert.Severity)
+		}
+		if window := alertWindow(alert); window != "" {
+			fmt.Fprintf(&b, " (%s)", window)
+		}
+	}
+	b.WriteString(backend.staleNote())
+	return newStructuredResult(b.String(), resp, prettyArg(request))
+}
+
+// alertWindow describes when an alert is in effect, e.g. "from ... until ...".
+func alertWindow(alert weatherAlert) string {
+	switch {
+	case alert.Start != nil && alert.End != nil:
+		return fmt.Sprintf("from %s until %s", alert.Start.Format(time.RFC3339), alert.End.Format(time.RFC3339))
+	case alert.Start != nil:
+		return "from " + alert.Start.Format(time.RFC3339)
+	case alert.End != nil:
+		return "until " + alert.End.Format(time.RFC3339)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,63 @@
+// auth.go
+// Backend authentication.
+//
+// AUTH_SCHEME controls how credentials are attached to every backend request:
+//
+//	query  - the API key as the "appid" query parameter (the default)
+//	header - the API key in the AUTH_HEADER header (defaults to X-API-Key)
+//	bearer - the API key as an "Authorization: Bearer" token
+//	basic  - HTTP Basic auth with BACKEND_USERNAME and BACKEND_PASSWORD
+//
+// Credentials are added after the cache key is built, so they never end up in it.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// loadAuth reads the authentication settings into c.
+func (c *config) loadAuth() error {
+	c.AuthScheme = strings.ToLower(envString("AUTH_SCHEME", "query"))
+	c.AuthHeader = envString("AUTH_HEADER", "X-API-Key")
+	switch c.AuthScheme {
+	case "query", "header", "bearer":
+		return nil
+	case "basic":
+		c.Username = envString("BACKEND_USERNAME", "")
+		if c.Username == "" {
+			return errors.New("BACKEND_USERNAME must be set when AUTH_SCHEME is basic")
+		}
+		password, err := loadSecret("BACKEND_PASSWORD")
+		if err != nil {
+			return err
+		}
+		c.Password = password
+		return nil
+	default:
+		return fmt.Errorf("invalid AUTH_SCHEME %q: must be query, header, basic or bearer", c.AuthScheme)
+	}
+}
+
+// setAuthParams adds the credentials that travel in the query string.
+func (c *config) setAuthParams(params url.Values) {
+	if c.AuthScheme == "query" {
+		params.Set("appid", c.APIKey)
+	}
+}
+
+// setAuthHeaders adds the credentials that travel in request headers.
+func (c *config) setAuthHeaders(req *http.Request) {
+	switch c.AuthScheme {
+	case "header":
+		req.Header.Set(c.AuthHeader, c.APIKey)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	case "basic":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
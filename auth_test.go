@@ -0,0 +1,117 @@
+// auth_test.go
+// Tests of the backend authentication schemes.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingCache caches nothing and records the keys it is asked about.
+type recordingCache struct {
+	noCache
+	mu   sync.Mutex
+	keys []string
+}
+
+func (c *recordingCache) get(ctx context.Context, key string) (cacheEntry, bool) {
+	c.record(key)
+	return c.noCache.get(ctx, key)
+}
+
+func (c *recordingCache) set(ctx context.Context, key string, body []byte, ttl time.Duration) {
+	c.record(key)
+}
+
+func (c *recordingCache) record(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = append(c.keys, key)
+}
+
+func TestAuthSchemes(t *testing.T) {
+	const apiKey = "s3cr3t-key"
+	tests := []struct {
+		scheme string
+		env    map[string]string
+		// check reports what is wrong with the credentials r carries, if anything.
+		check func(r *http.Request) string
+	}{
+		{"query", nil, func(r *http.Request) string {
+			if got := r.URL.Query().Get("appid"); got != apiKey {
+				return "appid = " + got
+			}
+			return ""
+		}},
+		{"header", map[string]string{"AUTH_HEADER": "X-Weather-Key"}, func(r *http.Request) string {
+			if got := r.Header.Get("X-Weather-Key"); got != apiKey {
+				return "X-Weather-Key = " + got
+			}
+			return ""
+		}},
+		{"bearer", nil, func(r *http.Request) string {
+			if got := r.Header.Get("Authorization"); got != "Bearer "+apiKey {
+				return "Authorization = " + got
+			}
+			return ""
+		}},
+		{"basic", map[string]string{"BACKEND_USERNAME": "weather", "BACKEND_PASSWORD": "hunter2"}, func(r *http.Request) string {
+			if user, password, ok := r.BasicAuth(); !ok || user != "weather" || password != "hunter2" {
+				return "basic auth = " + user + ":" + password
+			}
+			return ""
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests []*http.Request
+			)
+			env := map[string]string{"AUTH_SCHEME": tt.scheme, "WEATHER_API_KEY": apiKey}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			setupBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests = append(requests, r)
+				mu.Unlock()
+				jsonHandler(`{"temperature":20}`).ServeHTTP(w, r)
+			}), env)
+			keys := &recordingCache{}
+			cache = keys
+
+			if _, err := fetchBackend(context.Background(), "/temperature", url.Values{"location": {"Lisbon"}}); err != nil {
+				t.Fatalf("fetchBackend: %v", err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("backend received %d requests, want 1", len(requests))
+			}
+			r := requests[0]
+			if problem := tt.check(r); problem != "" {
+				t.Errorf("backend request has the wrong credentials: %s", problem)
+			}
+			// Only the query scheme puts the key in the URL.
+			if tt.scheme != "query" && r.URL.Query().Has("appid") {
+				t.Errorf("%s scheme sent the appid query parameter too", tt.scheme)
+			}
+			if tt.scheme != "bearer" && tt.scheme != "basic" && r.Header.Get("Authorization") != "" {
+				t.Errorf("%s scheme sent an Authorization header", tt.scheme)
+			}
+			if len(keys.keys) == 0 {
+				t.Fatal("the cache was never consulted")
+			}
+			for _, key := range keys.keys {
+				if strings.Contains(key, apiKey) || strings.Contains(key, "appid") || strings.Contains(key, "hunter2") {
+					t.Errorf("cache key %q carries the credentials", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+// backend.go
+// HTTP client for the underlying temperature service.
+//
+// All tool handlers talk to the backend through fetchBackend and the shared
+// httpClient so transport-level settings (TLS, timeouts, retries, ...) are
+// applied consistently. Concurrent identical requests (same path, location,
+// unit, ...) are collapsed into a single backend call whose result is shared
+// by every waiter.
+//
+// Large response bodies, and ones of unknown length such as chunked streams,
+// are decoded with a streaming JSON decoder as they arrive (see readBody).
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// httpClient is the client used for every backend request. main replaces it
+// with one built from the loaded configuration.
+var httpClient = http.DefaultClient
+
+// newHTTPClient builds the backend HTTP client for c.
+func newHTTPClient(c *config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Probe idle connections with TCP keepalives so ones silently dropped by
+	// a NAT or firewall are detected instead of failing the next request.
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: c.KeepAlive}
+	transport.DialContext = dialer.DialContext
+	// Refuse to negotiate anything older than the configured TLS version.
+	transport.TLSClientConfig = &tls.Config{MinVersion: c.TLSMinVersion}
+	// HTTP/2 is negotiated with HTTPS backends that offer it, unless it is
+	// turned off for proxies that break it.
+	if !c.HTTP2 {
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+	}
+	return &http.Client{Transport: transport, CheckRedirect: c.checkRedirect}
+}
+
+// checkRedirect follows at most MaxRedirects backend redirects, and only to
+// the endpoint's own host or one of RedirectHosts. Go drops the Authorization
+// header when a redirect changes host, so the credentials are attached again
+// to every request it follows.
+func (c *config) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > c.MaxRedirects {
+		return fmt.Errorf("backend redirected more than %d times", c.MaxRedirects)
+	}
+	if !c.redirectAllowed(req.URL) {
+		return fmt.Errorf("backend redirected to %s, which is not the endpoint host or in BACKEND_REDIRECT_HOSTS", req.URL.Host)
+	}
+	if c.AuthScheme == "query" && !req.URL.Query().Has("appid") {
+		q := req.URL.Query()
+		c.setAuthParams(q)
+		req.URL.RawQuery = q.Encode()
+	}
+	c.setAuthHeaders(req)
+	return nil
+}
+
+// redirectAllowed reports whether a redirect to u may be followed.
+func (c *config) redirectAllowed(u *url.URL) bool {
+	host, hostname := strings.ToLower(u.Host), strings.ToLower(u.Hostname())
+	if host == strings.ToLower(c.backendHost()) {
+		return true
+	}
+	for _, allowed := range c.RedirectHosts {
+		if allowed == host || allowed == hostname {
+			return true
+		}
+	}
+	return false
+}
+
+// backendFlight deduplicates concurrent identical backend requests, keyed
+// like the cache.
+var backendFlight singleflight.Group
+
+// timeoutKey is the context key holding the backend timeout of the current tool.
+type timeoutKey struct{}
+
+// withBackendTimeout returns a context whose backend requests use timeout d.
+func withBackendTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, d)
+}
+
+// backendTimeout returns the per-request timeout for backend calls made with
+// ctx, falling back to the global BACKEND_TIMEOUT.
+func backendTimeout(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(timeoutKey{}).(time.Duration); ok {
+		return d
+	}
+	return cfg.Timeout
+}
+
+// backendResponse is a backend response body and where it came from.
+type backendResponse struct {
+	Body []byte
+	// Stale is set when Body is an expired cache entry served because the
+	// backend could not be reached.
+	Stale bool
+	// LastKnownGood is set, along with Stale, when Body comes from the
+	// last-known-good store rather than the cache.
+	LastKnownGood bool
+	// FetchedAt is when Body was fetched from the backend.
+	FetchedAt time.Time
+}
+
+// staleNote returns the annotation appended to tool output built from a
+// stale response, or "" for a fresh one.
+func (r backendResponse) staleNote() string {
+	if !r.Stale {
+		return ""
+	}
+	if r.LastKnownGood {
+		return fmt.Sprintf(" [last known good: backend unavailable, showing data fetched at %s]", r.FetchedAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf(" [stale: backend unavailable, showing data cached at %s]", r.FetchedAt.Format(time.RFC3339))
+}
+
+// fetchBackend sends a GET request for path with the given query parameters
+// to the temperature service and returns the response body. Each attempt is
+// bounded by the calling tool's backend timeout, and transient failures are
+// retried with exponential backoff. If every attempt fails, a cached entry is
+// returned instead of the error: a fresh one passed over for fresh data, or
+// an expired one still inside CACHE_STALE_GRACE, marked stale. Failing that,
+// the last-known-good response is returned when LAST_KNOWN_GOOD is set.
+func fetchBackend(ctx context.Context, path string, params url.Values) (backendResponse, error) {
+	path = cfg.apiPath(path)
+
+	// Serve repeated queries from the cache. The key is built before the API
+	// key is added so it never ends up in cache keys. It includes the API
+	// version, so a shared cache never mixes responses from two versions.
+	cacheKey := path + "?" + params.Encode()
+	entry, cached := cache.get(ctx, cacheKey)
+	if cached && entry.fresh(time.Now()) && !freshRequested(ctx) {
+		logf(ctx, "[fetchBackend] Cache hit for %s", cacheKey)
+		recordBackend(ctx, true, 0)
+		return backendResponse{Body: entry.body, FetchedAt: entry.storedAt}, nil
+	}
+	// Whatever the cache says, the same query never reaches the backend more
+	// often than MIN_REFRESH_INTERVAL.
+	if recent, ok := recentFetches.get(cacheKey, time.Now()); ok {
+		logf(ctx, "[fetchBackend] %s was fetched %s ago, within MIN_REFRESH_INTERVAL; serving that response",
+			cacheKey, time.Since(recent.fetchedAt).Round(time.Millisecond))
+		recordBackend(ctx, true, 0)
+		return backendResponse{Body: recent.body, FetchedAt: recent.fetchedAt}, nil
+	}
+
+	// Attach query-string credentials; header-based ones are set per request.
+	if cfg.APIKey == "" && cfg.AuthScheme != "basic" {
+		logf(ctx, "[fetchBackend] WARNING: WEATHER_API_KEY is not set!")
+	}
+	cfg.setAuthParams(params)
+
+	// Step 1: Prepare the request URL for the HTTP temperature service.
+	reqUrl := backendURL(cfg.Endpoint, path, params)
+	// The URL carries the API key, so it only goes to the file log.
+	log.Printf("[fetchBackend] Requesting URL: %s", logCoordinates(reqUrl))
+
+	start := time.Now()
+	body, keep, err := fetchShared(ctx, cache, cacheKey, reqUrl)
+	if err == nil {
+		now := time.Now()
+		recordBackend(ctx, false, now.Sub(start))
+		if keep {
+			lastGood.set(cacheKey, body, now)
+			recentFetches.set(cacheKey, body, now)
+		}
+		return backendResponse{Body: body, FetchedAt: now}, nil
+	}
+	// A cancelled request has no one left to serve, stale or not.
+	if errors.Is(err, context.Canceled) {
+		return backendResponse{}, err
+	}
+	// A cached entry is served in its place: one still fresh, which the call
+	// skipped only to ask for fresh data, as it is, and an expired one inside
+	// CACHE_STALE_GRACE marked stale.
+	if stale := cached && !entry.fresh(time.Now()); cached && (!stale || cfg.CacheStaleGrace > 0) {
+		if stale {
+			logf(ctx, "[fetchBackend] WARNING: serving stale cache entry for %s from %s: %v",
+				cacheKey, entry.storedAt.Format(time.RFC3339), err)
+		} else {
+			logf(ctx, "[fetchBackend] WARNING: serving cache entry for %s from %s instead of fresh data: %v",
+				cacheKey, entry.storedAt.Format(time.RFC3339), err)
+		}
+		recordBackend(ctx, true, time.Since(start))
+		return backendResponse{Body: entry.body, Stale: stale, FetchedAt: entry.storedAt}, nil
+	}
+	if good, ok := lastGood.get(cacheKey); ok {
+		logf(ctx, "[fetchBackend] WARNING: serving last known good response for %s from %s: %v",
+			cacheKey, good.fetchedAt.Format(time.RFC3339), err)
+		recordBackend(ctx, true, time.Since(start))
+		return backendResponse{Body: good.body, Stale: true, LastKnownGood: true, FetchedAt: good.fetchedAt}, nil
+	}
+	return backendResponse{}, err
+}
+
+// fetchMetricReading fetches the per-metric reading at path for query, with
+// the extra query parameters, and decodes it into v. what names the reading
+// in the error when the response cannot be parsed, e.g. "wind".
+func fetchMetricReading(ctx context.Context, query locationQuery, path string, extra url.Values, what string, v any) (backendResponse, error) {
+	params := url.Values{}
+	query.setParams(params)
+	for k, values := range extra {
+		params[k] = values
+	}
+	backend, err := fetchBackend(ctx, path, params)
+	if err != nil {
+		return backendResponse{}, err
+	}
+	if err := json.Unmarshal(backend.Body, v); err != nil {
+		return backendResponse{}, invalidResponsef("failed to parse %s response: %w", what, err)
+	}
+	return backend, nil
+}
+
+// refreshBackend fetches path with params from the backend and caches the
+// response, replacing any cached entry, fresh or not.
+func refreshBackend(ctx context.Context, path string, params url.Values) error {
+	path = cfg.apiPath(path)
+	cacheKey := path + "?" + params.Encode()
+	if _, ok := recentFetches.get(cacheKey, time.Now()); ok {
+		log.Printf("[refreshBackend] Skipping %s: fetched within MIN_REFRESH_INTERVAL", cacheKey)
+		return nil
+	}
+	cfg.setAuthParams(params)
+	reqUrl := backendURL(cfg.Endpoint, path, params)
+	log.Printf("[refreshBackend] Requesting URL: %s", logCoordinates(reqUrl))
+	body, keep, err := fetchShared(ctx, cache, cacheKey, reqUrl)
+	if err != nil || !keep {
+		return err
+	}
+	now := time.Now()
+	lastGood.set(cacheKey, body, now)
+	recentFetches.set(cacheKey, body, now)
+	return nil
+}
+
+// fetchShared fetches reqUrl and caches the body in store under cacheKey, for
+// as long as the response's Cache-Control header allows, sharing one backend
+// call between all concurrent callers with the same key. keep is false when
+// the header forbids keeping the body anywhere else either (see noStore).
+// The shared call does not inherit any one caller's cancellation, so a client giving up
+// does not fail the others; each caller still stops waiting when its own
+// context ends. It does keep the deadline of the caller that started it, so
+// its retries stop once that caller's time is up. The shared call runs in
+// its own goroutine, out of the handlers' recover, so it recovers a panic
+// itself and fails every waiter with an error instead of taking down the
+// server.
+func fetchShared(ctx context.Context, store responseCache, cacheKey, reqUrl string) (body []byte, keep bool, err error) {
+	ch := backendFlight.DoChan(cacheKey, func() (val any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[fetchBackend] PANIC in shared backend call for %s: %v\n%s", cacheKey, r, debug.Stack())
+				val, err = nil, errors.New("internal error")
+			}
+		}()
+		shared := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			shared, cancel = context.WithDeadline(shared, deadline)
+			defer cancel()
+		}
+		body, header, err := fetchWithRetry(shared, reqUrl)
+		if err != nil {
+			return nil, err
+		}
+		if ttl, ok := cacheLifetime(header); ok {
+			store.set(shared, cacheKey, body, ttl)
+		} else {
+			logf(ctx, "[fetchBackend] Not caching %s: Cache-Control %q", cacheKey, header.Get("Cache-Control"))
+		}
+		return sharedResponse{body: body, keep: !noStore(header)}, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Shared {
+			logf(ctx, "[fetchBackend] Shared backend response for %s", cacheKey)
+		}
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+		shared := res.Val.(sharedResponse)
+		return shared.body, shared.keep, nil
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// sharedResponse is the result of a shared backend call.
+type sharedResponse struct {
+	body []byte
+	// keep is false for no-store responses.
+	keep bool
+}
+
+// fetchWithRetry fetches reqUrl, retrying transient failures.
+func fetchWithRetry(ctx context.Context, reqUrl string) ([]byte, http.Header, error) {
+	for attempt := 1; ; attempt++ {
+		body, header, err := fetchOnce(ctx, reqUrl, attemptTimeout(ctx, attempt))
+		if err == nil {
+			return body, header, nil
+		}
+		if attempt >= cfg.RetryMaxAttempts || !methodRetryable(backendMethod) || !isRetryable(err) {
+			return nil, nil, err
+		}
+		delay := backoffDelay(attempt)
+		if !retryAllowed(ctx, delay) {
+			logf(ctx, "[fetchBackend] WARNING: retry budget exhausted after %d attempts: %v", attempt, err)
+			return nil, nil, err
+		}
+		logf(ctx, "[fetchBackend] Attempt %d failed, retrying in %s: %v", attempt, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// backendMethod is the HTTP method of tool backend requests.
+const backendMethod = http.MethodGet
+
+// backendURL builds the request URL for path under endpoint with params.
+func backendURL(endpoint, path string, params url.Values) string {
+	return endpoint + path + "?" + params.Encode()
+}
+
+// fetchOnce performs a single backendMethod request for reqUrl, bounded by
+// timeout, and returns the body and headers of a successful response.
+func fetchOnce(ctx context.Context, reqUrl string, timeout time.Duration) ([]byte, http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, backendMethod, reqUrl, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build backend request: %w", err)
+	}
+	cfg.setAuthHeaders(req)
+
+	// Step 2: Make an HTTP GET request to the temperature service.
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// Report the cause without the request URL, which may carry the API key.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		logf(ctx, "[fetchOnce] ERROR: failed to query temperature service: %v", err)
+		// A refused connection almost always means the backend isn't running,
+		// which is the most common first-run problem; say so plainly.
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, nil, withClass(ErrBackendUnavailable, fmt.Errorf("could not connect to the temperature service at %s (%w). "+
+				"Is the backend running? Set TEMPERATURE_API_ENDPOINT to its address if it runs elsewhere",
+				cfg.backendHost(), syscall.ECONNREFUSED))
+		}
+		return nil, nil, withClass(ErrBackendUnavailable, fmt.Errorf("failed to query temperature service: %w", err))
+	}
+	logf(ctx, "[fetchOnce] HTTP response status: %s", resp.Status)
+	defer resp.Body.Close()
+
+	// Step 3: Check for a successful response.
+	if resp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, int64(cfg.LogBodyLimit)+1))
+		logf(ctx, "[fetchOnce] ERROR: unexpected status %s, body: %s", resp.Status, logBody(errBody))
+		return nil, nil, &statusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	// Step 4: Read the response body.
+	body, err := readBody(ctx, resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	debugf(ctx, "[fetchOnce] Response body: %s", logBody(body))
+	return body, resp.Header, nil
+}
+
+// defaultStreamThreshold is the default BACKEND_STREAM_THRESHOLD, in bytes.
+const defaultStreamThreshold = 64 << 10
+
+// readBody reads the body of a successful backend response. Responses
+// smaller than BACKEND_STREAM_THRESHOLD are read whole. Larger ones, and ones
+// of unknown length, go through a streaming JSON decoder instead, which
+// consumes the body as it arrives: reading stops as soon as the JSON value is
+// complete, even if the backend is slow to end its stream, and a malformed
+// body fails at its first bad token rather than after the last byte, unless
+// that token is a NaN or Infinity (see nullNonFinite), which the tools treat
+// as missing data: the rest of the body is then read as usual. Either way
+// reading is bound to ctx and its deadline.
+func readBody(ctx context.Context, resp *http.Response) ([]byte, error) {
+	if cfg.StreamThreshold <= 0 || (resp.ContentLength >= 0 && resp.ContentLength < int64(cfg.StreamThreshold)) {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, readError(ctx, err)
+		}
+		return body, nil
+	}
+	var body json.RawMessage
+	var seen bytes.Buffer
+	if err := json.NewDecoder(io.TeeReader(resp.Body, &seen)).Decode(&body); err != nil {
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return nil, readError(ctx, err)
+		}
+		rest, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return nil, readError(ctx, readErr)
+		}
+		full := append(seen.Bytes(), rest...)
+		if cleaned, ok := nullNonFinite(full); !ok || !json.Valid(cleaned) {
+			return nil, invalidResponsef("failed to parse response: %w", err)
+		}
+		return full, nil
+	}
+	logf(ctx, "[fetchOnce] Streamed %d-byte response body", len(body))
+	return body, nil
+}
+
+// readError describes a failure to read a response body, as a timeout when
+// ctx ran out.
+func readError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		err = fmt.Errorf("%w: %w", ctxErr, err)
+	}
+	return withClass(ErrBackendUnavailable, fmt.Errorf("failed to read response: %w", err))
+}
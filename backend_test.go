@@ -0,0 +1,221 @@
+// backend_test.go
+// Tests of the backend client.
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestFetchSharedDeadline checks that a shared backend call stops retrying
+// at the deadline of the caller that started it, even while another caller
+// with no deadline of its own is waiting on it.
+func TestFetchSharedDeadline(t *testing.T) {
+	var calls atomic.Int32
+	backend := setupBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "try again later", http.StatusServiceUnavailable)
+	}), map[string]string{
+		"RETRY_MAX_ATTEMPTS": "10",
+		"RETRY_BASE_DELAY":   "100ms",
+		"RETRY_MAX_DELAY":    "100ms",
+		"RETRY_BUDGET":       "0",
+	})
+	reqUrl := backend.URL + "/temperature?location=Lisbon"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	go fetchShared(ctx, cache, t.Name(), reqUrl)
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	if _, _, err := fetchShared(context.Background(), cache, t.Name(), reqUrl); err == nil {
+		t.Fatal("fetchShared succeeded against a failing backend")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("the shared call ran for %s, past the 250ms deadline of its leader", elapsed)
+	}
+	if n := calls.Load(); n > 3 {
+		t.Errorf("backend called %d times within a 250ms deadline and 100ms between retries, want at most 3", n)
+	}
+}
+
+// TestFetchBackendCachedFallback checks which cached entries are served, and
+// marked stale, when the backend fails.
+func TestFetchBackendCachedFallback(t *testing.T) {
+	var failing atomic.Bool
+	setupBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"temperature":20}`)
+	}), map[string]string{
+		"CACHE_TTL":          "100ms",
+		"CACHE_STALE_GRACE":  "1h",
+		"RETRY_MAX_ATTEMPTS": "1",
+	})
+	params := func() url.Values { return url.Values{"location": {"Lisbon"}} }
+	if _, err := fetchBackend(context.Background(), "/temperature", params()); err != nil {
+		t.Fatalf("fetchBackend: %v", err)
+	}
+	failing.Store(true)
+
+	// Still fresh, passed over only because the call asked for fresh data.
+	resp, err := fetchBackend(withFresh(context.Background()), "/temperature", params())
+	if err != nil {
+		t.Fatalf("fetchBackend with fresh: %v", err)
+	}
+	if resp.Stale {
+		t.Error("a fresh cache entry served in place of fresh data is marked stale")
+	}
+
+	// Expired, inside the grace period.
+	time.Sleep(150 * time.Millisecond)
+	if resp, err = fetchBackend(context.Background(), "/temperature", params()); err != nil {
+		t.Fatalf("fetchBackend after expiry: %v", err)
+	}
+	if !resp.Stale {
+		t.Error("an expired cache entry is not marked stale")
+	}
+}
+
+// TestFetchBackendNoStore checks that no-store responses are kept nowhere,
+// while no-cache ones still count for MIN_REFRESH_INTERVAL and
+// LAST_KNOWN_GOOD.
+func TestFetchBackendNoStore(t *testing.T) {
+	for _, tt := range []struct {
+		cacheControl string
+		kept         bool
+	}{
+		{"no-store", false},
+		{"private, No-Store", false},
+		{"no-cache", true},
+	} {
+		t.Run(tt.cacheControl, func(t *testing.T) {
+			var calls atomic.Int32
+			var failing atomic.Bool
+			setupBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if failing.Load() {
+					http.Error(w, "down", http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				io.WriteString(w, `{"temperature":20}`)
+			}), map[string]string{
+				"MIN_REFRESH_INTERVAL": "1h",
+				"LAST_KNOWN_GOOD":      "true",
+				"RETRY_MAX_ATTEMPTS":   "1",
+			})
+			params := func() url.Values { return url.Values{"location": {"Lisbon"}} }
+			for i := 0; i < 2; i++ {
+				if _, err := fetchBackend(context.Background(), "/temperature", params()); err != nil {
+					t.Fatalf("fetchBackend: %v", err)
+				}
+			}
+			if want := map[bool]int32{true: 1, false: 2}[tt.kept]; calls.Load() != want {
+				t.Errorf("backend called %d times for two fetches within MIN_REFRESH_INTERVAL, want %d", calls.Load(), want)
+			}
+
+			// Past the interval, only a kept response can stand in for a failure.
+			recentFetches = nil
+			failing.Store(true)
+			resp, err := fetchBackend(context.Background(), "/temperature", params())
+			if tt.kept && (err != nil || !resp.LastKnownGood) {
+				t.Errorf("fetchBackend = %+v, %v; want the last known good response", resp, err)
+			}
+			if !tt.kept && err == nil {
+				t.Errorf("fetchBackend served %s from a no-store response", resp.Body)
+			}
+		})
+	}
+}
+
+// TestCheckRedirectCredentials checks that redirects to the endpoint's own
+// host and to BACKEND_REDIRECT_HOSTS carry the credentials, and that others
+// are not followed.
+func TestCheckRedirectCredentials(t *testing.T) {
+	const apiKey = "s3cr3t-key"
+	tests := []struct {
+		name      string
+		scheme    string
+		crossHost bool
+		allowed   bool
+	}{
+		{"same host, query", "query", false, true},
+		{"same host, bearer", "bearer", false, true},
+		{"cross host, query", "query", true, true},
+		{"cross host, bearer", "bearer", true, true},
+		{"cross host not allowed", "bearer", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// target records the credentials the redirect target receives.
+			var targetCalls atomic.Int32
+			var gotAppID, gotAuthorization atomic.Value
+			target := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				targetCalls.Add(1)
+				gotAppID.Store(r.URL.Query().Get("appid"))
+				gotAuthorization.Store(r.Header.Get("Authorization"))
+				jsonHandler(`{"temperature":20}`).ServeHTTP(w, r)
+			})
+			other := httptest.NewServer(target)
+			t.Cleanup(other.Close)
+
+			mux := http.NewServeMux()
+			mux.Handle("/moved", target)
+			redirectTo := "/moved?location=Lisbon"
+			if tt.crossHost {
+				redirectTo = other.URL + redirectTo
+			}
+			mux.HandleFunc("/temperature", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, redirectTo, http.StatusFound)
+			})
+			env := map[string]string{
+				"AUTH_SCHEME":        tt.scheme,
+				"WEATHER_API_KEY":    apiKey,
+				"CACHE_TTL":          "0",
+				"RETRY_MAX_ATTEMPTS": "1",
+			}
+			if tt.allowed {
+				env["BACKEND_REDIRECT_HOSTS"] = other.Listener.Addr().String()
+			}
+			setupBackend(t, mux, env)
+
+			_, err := fetchBackend(context.Background(), "/temperature", url.Values{"location": {"Lisbon"}})
+			if !tt.allowed {
+				if err == nil {
+					t.Error("fetchBackend followed a redirect to a host outside BACKEND_REDIRECT_HOSTS")
+				}
+				if n := targetCalls.Load(); n != 0 {
+					t.Errorf("disallowed redirect target received %d requests, want none", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchBackend: %v", err)
+			}
+			if n := targetCalls.Load(); n != 1 {
+				t.Fatalf("redirect target received %d requests, want 1", n)
+			}
+			wantAppID, wantAuthorization := "", "Bearer "+apiKey
+			if tt.scheme == "query" {
+				wantAppID, wantAuthorization = apiKey, ""
+			}
+			if got := gotAppID.Load(); got != wantAppID {
+				t.Errorf("redirect target got appid %q, want %q", got, wantAppID)
+			}
+			if got := gotAuthorization.Load(); got != wantAuthorization {
+				t.Errorf("redirect target got Authorization %q, want %q", got, wantAuthorization)
+			}
+		})
+	}
+}
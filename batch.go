@@ -0,0 +1,218 @@
+// batch.go
+// The "get_temperatures_batch" tool.
+//
+// get_temperatures_batch takes an array of {location, unit} items and returns
+// one typed result per item, in order, each with its own status, so
+// programmatic clients need not pick apart the combined output of
+// get_temperature. Items are fetched concurrently, at most BATCH_CONCURRENCY
+// at a time, and an item that fails never fails the rest of the batch. A
+// batch of more than maxBatchItems is rejected, unless "truncate_overflow"
+// asks for the first maxBatchItems to be processed and the rest reported as
+// skipped.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxBatchItems caps the number of items in a single batch.
+const maxBatchItems = 50
+
+// defaultBatchConcurrency is the default number of batch items fetched at once.
+const defaultBatchConcurrency = 4
+
+// newTemperatureBatchTool defines the "get_temperatures_batch" tool.
+func newTemperatureBatchTool() mcp.Tool {
+	return mcp.NewTool("get_temperatures_batch",
+		readOnlyAnnotation("Temperature Batch", true),
+		mcp.WithDescription("Get the temperature for several locations at once, each in its own unit, with a typed result and status per item"),
+		mcp.WithArray("items",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Locations to query (at most %d), each with an optional unit: metric, imperial or kelvin (defaults to metric)", maxBatchItems)),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"location": map[string]any{"type": "string"},
+					"unit":     map[string]any{"type": "string"},
+				},
+				"required": []string{"location"},
+			}),
+		),
+		mcp.WithBoolean("truncate_overflow",
+			mcp.Description(fmt.Sprintf("When there are more than %d items, process the first %d and report the rest as skipped instead of failing; defaults to the server's BATCH_TRUNCATE_OVERFLOW, off unless set", maxBatchItems, maxBatchItems)),
+		),
+		mcp.WithNumber("precision",
+			mcp.Description("Number of decimals to show (0-6); defaults to the value as reported"),
+		),
+		mcp.WithString("rounding",
+			mcp.Description("How to round to the precision: round (half to even, the default), floor or ceil; on its own it rounds to whole numbers"),
+			mcp.Enum("round", "floor", "ceil"),
+		),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// batchItem is one entry of the "items" argument.
+type batchItem struct {
+	Location string
+	Unit     string
+}
+
+// batchItemResult is the outcome of one batch item.
+type batchItemResult struct {
+	// Index is the item's position in the request, from 0.
+	Index    int    `json:"index"`
+	Location string `json:"location"`
+	// Status is "ok" when Result holds the reading, "error" otherwise.
+	Status string             `json:"status"`
+	Result *temperatureResult `json:"result,omitempty"`
+	// Error and ErrorClass explain a failed item; the class is one of the
+	// error classes logged for failed calls.
+	Error      string `json:"error,omitempty"`
+	ErrorClass string `json:"error_class,omitempty"`
+}
+
+// batchResult is the structured result of get_temperatures_batch.
+type batchResult struct {
+	Items     []batchItemResult `json:"items"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	// Skipped is the number of items past maxBatchItems left out with
+	// truncate_overflow.
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// temperatureBatchHandler handles incoming requests to the "get_temperatures_batch" tool.
+func temperatureBatchHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[temperatureBatchHandler] Received Params: %+v", request.Params.Arguments)
+
+	truncate := mcp.ParseBoolean(request, "truncate_overflow", cfg.BatchTruncateOverflow)
+	items, skipped, err := batchItemsArg(request.Params.Arguments, truncate)
+	if err != nil {
+		logf(ctx, "[temperatureBatchHandler] ERROR: %v", err)
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	pretty := prettyArg(request)
+
+	// Fetch every item, at most BATCH_CONCURRENCY at once, keeping per-item
+	// errors.
+	results := make([]batchItemResult, len(items))
+	progress := newProgressReporter(ctx, request, len(items))
+	slots := make(chan struct{}, cfg.BatchConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				results[i] = failedBatchItem(i, item.Location, ctx.Err())
+				return
+			}
+			results[i] = batchItemFor(ctx, i, item, format)
+			if results[i].Result != nil {
+				progress.step(results[i].Result.Text)
+			} else {
+				progress.step(fmt.Sprintf("Temperature for %s: unavailable (%s)", item.Location, results[i].Error))
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := batchResult{Items: results, Skipped: skipped}
+	var lines []string
+	for _, r := range results {
+		if r.Status == "ok" {
+			out.Succeeded++
+			lines = append(lines, r.Result.Text)
+		} else {
+			out.Failed++
+			lines = append(lines, fmt.Sprintf("Temperature for %s: unavailable (%s)", r.Location, r.Error))
+		}
+	}
+	if out.Failed > 0 {
+		lines = append(lines, fmt.Sprintf("%d of %d items failed", out.Failed, len(results)))
+	}
+	if skipped > 0 {
+		lines = append(lines, fmt.Sprintf("Warning: only the first %d items were processed; the remaining %d were skipped", maxBatchItems, skipped))
+		logf(ctx, "[temperatureBatchHandler] WARNING: skipped %d items past the limit of %d", skipped, maxBatchItems)
+	}
+	return newStructuredResult(strings.Join(lines, "\n"), out, pretty)
+}
+
+// batchItemsArg reads the "items" argument. Every item must be an object
+// with a non-empty "location" and, optionally, a string "unit". With
+// truncate, the items past maxBatchItems are dropped and counted in skipped
+// instead of failing the call; they are not validated.
+func batchItemsArg(args map[string]any, truncate bool) (items []batchItem, skipped int, err error) {
+	list, ok := args["items"].([]any)
+	if !ok || len(list) == 0 {
+		return nil, 0, invalidArgumentf("items must be a non-empty array of {location, unit} objects")
+	}
+	if len(list) > maxBatchItems {
+		if !truncate {
+			return nil, 0, invalidArgumentf("items has %d entries, at most %d are allowed (set truncate_overflow to process the first %d)", len(list), maxBatchItems, maxBatchItems)
+		}
+		list, skipped = list[:maxBatchItems], len(list)-maxBatchItems
+	}
+	items = make([]batchItem, len(list))
+	for i, raw := range list {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return nil, 0, invalidArgumentf("items[%d] must be an object with a location", i)
+		}
+		location, _ := obj["location"].(string)
+		if strings.TrimSpace(location) == "" {
+			return nil, 0, invalidArgumentf("items[%d] must have a non-empty location", i)
+		}
+		unit, ok := obj["unit"].(string)
+		if _, given := obj["unit"]; given && !ok {
+			return nil, 0, invalidArgumentf("items[%d].unit must be a string", i)
+		}
+		items[i] = batchItem{Location: location, Unit: unit}
+	}
+	return items, skipped, nil
+}
+
+// batchItemFor fetches the temperature for one batch item.
+func batchItemFor(ctx context.Context, index int, item batchItem, format numberFormat) batchItemResult {
+	unit, err := normalizeUnit(item.Unit)
+	if err == nil {
+		err = checkUnitSupported(unit)
+	}
+	if err != nil {
+		return failedBatchItem(index, item.Location, err)
+	}
+	result, err := safeTemperatureFor(ctx, item.Location, temperatureOptions{Unit: unit, Format: format, Lang: cfg.DefaultLang})
+	if err != nil {
+		logf(ctx, "[temperatureBatchHandler] Item %d (%q) failed: %v", index, item.Location, err)
+		return failedBatchItem(index, item.Location, err)
+	}
+	return batchItemResult{Index: index, Location: item.Location, Status: "ok", Result: &result}
+}
+
+// failedBatchItem describes a batch item that could not be fetched.
+func failedBatchItem(index int, location string, err error) batchItemResult {
+	return batchItemResult{
+		Index:      index,
+		Location:   location,
+		Status:     "error",
+		Error:      failureReason(err),
+		ErrorClass: errorClass(err),
+	}
+}
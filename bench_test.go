@@ -0,0 +1,62 @@
+// bench_test.go
+// Benchmarks of the get_temperature hot path.
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+// benchReading is the backend response the benchmarks are served.
+const benchReading = `{"location":"Lisbon","temperature":18.5,"humidity":72,"feels_like":18.1,"conditions":"clear"}`
+
+// benchmarkTemperatureHandler calls get_temperature for one location against
+// a local backend, with env on top of the environment.
+func benchmarkTemperatureHandler(b *testing.B, env map[string]string) {
+	setupBackend(b, jsonHandler(benchReading), env)
+	ctx := context.Background()
+	request := toolRequest(map[string]any{"location": "Lisbon"})
+	if _, err := temperatureHandler(ctx, request); err != nil {
+		b.Fatalf("temperatureHandler: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := temperatureHandler(ctx, request); err != nil {
+			b.Fatalf("temperatureHandler: %v", err)
+		}
+	}
+}
+
+// BenchmarkTemperatureHandlerCacheHit measures a call answered from the cache.
+func BenchmarkTemperatureHandlerCacheHit(b *testing.B) {
+	benchmarkTemperatureHandler(b, map[string]string{"CACHE_TTL": "1h"})
+}
+
+// BenchmarkTemperatureHandlerCacheMiss measures a call that reaches the backend.
+func BenchmarkTemperatureHandlerCacheMiss(b *testing.B) {
+	benchmarkTemperatureHandler(b, map[string]string{"CACHE_TTL": "0"})
+}
+
+// BenchmarkBackendURL measures building a backend request URL.
+func BenchmarkBackendURL(b *testing.B) {
+	params := url.Values{"location": {"Lisbon"}, "units": {"metric"}, "lang": {"pt"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		backendURL("http://localhost:8080", "/temperature", params)
+	}
+}
+
+// BenchmarkParseReading measures decoding a backend temperature response.
+func BenchmarkParseReading(b *testing.B) {
+	setupConfig(b, nil)
+	body := []byte(benchReading)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseReading(body); err != nil {
+			b.Fatalf("parseReading: %v", err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// cache.go
+// Cache of backend responses.
+//
+// The cache stores the raw backend body keyed by the backend path and query
+// (units, location, date, ...), never the formatted output. Everything that
+// only affects presentation, such as trend or formatting options, is applied
+// per request on top of the cached body, so two requests that differ only in
+// how they want the answer shown can never be served each other's output.
+//
+// Entries live in process memory by default, at most CACHE_MAX_ENTRIES of
+// them: when the cache is full, the least recently used entry is evicted, so
+// memory stays bounded however many distinct locations are queried.
+// CACHE_BACKEND=redis shares them between every server instance pointed at
+// the same Redis, which bounds its own memory.
+//
+// Each entry lives for the max-age of the backend's Cache-Control header
+// when it sends one, and for CACHE_TTL otherwise; responses marked no-store
+// or no-cache are not cached at all (see cacheLifetime). no-store ones are
+// not kept anywhere else either, last-known-good store included (see
+// noStore).
+//
+// With CACHE_STALE_GRACE set, entries are kept for that long past their TTL.
+// They are never served while the backend answers, but when it fails an
+// expired entry still inside the grace period is returned instead of the
+// error, marked as stale.
+
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheMaxEntries is the default CACHE_MAX_ENTRIES.
+const defaultCacheMaxEntries = 1000
+
+// responseCache stores backend response bodies for a fixed TTL plus an
+// optional stale grace period.
+type responseCache interface {
+	// get returns the entry cached under key, if it is still within its TTL
+	// or grace period. Callers check entry.fresh before serving it normally.
+	get(ctx context.Context, key string) (cacheEntry, bool)
+	// set stores body under key for ttl, or the cache's default TTL when
+	// ttl is zero, plus the grace period.
+	set(ctx context.Context, key string, body []byte, ttl time.Duration)
+	// status describes the cache for server_info.
+	status() string
+}
+
+// cache is the shared response cache.
+var cache responseCache = noCache{}
+
+// newCache builds the response cache selected by c.
+func newCache(c *config) (responseCache, error) {
+	if c.CacheTTL <= 0 {
+		return noCache{}, nil
+	}
+	switch c.CacheBackend {
+	case "memory":
+		return newMemoryCache(c.CacheTTL, c.CacheStaleGrace, c.CacheMaxEntries), nil
+	case "redis":
+		return newRedisCache(c.RedisURL, c.CacheTTL, c.CacheStaleGrace)
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", c.CacheBackend)
+	}
+}
+
+// noCache is used when caching is disabled.
+type noCache struct{}
+
+func (noCache) get(context.Context, string) (cacheEntry, bool)     { return cacheEntry{}, false }
+func (noCache) set(context.Context, string, []byte, time.Duration) {}
+func (noCache) status() string                                     { return "disabled" }
+
+// cacheEntry is a cached body and the times it stops being fresh and stops
+// being servable at all.
+type cacheEntry struct {
+	body     []byte
+	storedAt time.Time
+	expires  time.Time
+	// staleUntil is the end of the grace period; equal to expires when
+	// stale serving is disabled.
+	staleUntil time.Time
+}
+
+// newCacheEntry returns an entry for body stored at now.
+func newCacheEntry(body []byte, now time.Time, ttl, grace time.Duration) cacheEntry {
+	return cacheEntry{
+		body:       body,
+		storedAt:   now,
+		expires:    now.Add(ttl),
+		staleUntil: now.Add(ttl + grace),
+	}
+}
+
+// fresh reports whether the entry is still within its TTL.
+func (e cacheEntry) fresh(now time.Time) bool { return now.Before(e.expires) }
+
+// memoryCache is a concurrency-safe, in-process TTL cache holding at most
+// maxEntries entries, evicted least recently used first.
+type memoryCache struct {
+	ttl        time.Duration
+	grace      time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru orders the entries from most to least recently used; each element
+	// holds a *memoryCacheItem.
+	lru *list.List
+}
+
+// memoryCacheItem is an element of memoryCache.lru.
+type memoryCacheItem struct {
+	key   string
+	entry cacheEntry
+}
+
+// newMemoryCache returns an empty in-memory cache of up to maxEntries
+// entries, fresh for ttl and kept for grace beyond it.
+func newMemoryCache(ttl, grace time.Duration, maxEntries int) *memoryCache {
+	return &memoryCache{
+		ttl:        ttl,
+		grace:      grace,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (c *memoryCache) get(_ context.Context, key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	item := elem.Value.(*memoryCacheItem)
+	if !time.Now().Before(item.entry.staleUntil) {
+		c.remove(elem)
+		return cacheEntry{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return item.entry, true
+}
+
+func (c *memoryCache) set(_ context.Context, key string, body []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	entry := newCacheEntry(body, time.Now(), ttl, c.grace)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*memoryCacheItem).entry = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops elem from the cache. c.mu must be held.
+func (c *memoryCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryCacheItem).key)
+}
+
+func (c *memoryCache) status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprintf("enabled (memory, default ttl %s%s, %d/%d entries)", c.ttl, graceStatus(c.grace), c.lru.Len(), c.maxEntries)
+}
+
+// cacheLifetime returns how long a backend response with header h may be
+// cached: the max-age of its Cache-Control header, or zero, meaning the
+// cache's default TTL, when it gives none. store is false when the header
+// forbids caching with no-store, no-cache or a max-age of zero.
+func cacheLifetime(h http.Header) (ttl time.Duration, store bool) {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return 0, false
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil || seconds < 0 {
+				continue
+			}
+			if seconds == 0 {
+				return 0, false
+			}
+			ttl = time.Duration(seconds) * time.Second
+		}
+	}
+	return ttl, true
+}
+
+// noStore reports whether header h forbids keeping the response at all,
+// with Cache-Control: no-store. Such responses are not cached, and unlike
+// no-cache ones, not kept by the last-known-good or recent-fetch stores.
+func noStore(h http.Header) bool {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+// graceStatus describes the stale grace period for status lines.
+func graceStatus(grace time.Duration) string {
+	if grace <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(", stale grace %s", grace)
+}
@@ -0,0 +1,43 @@
+// cache_test.go
+// Tests of the in-memory response cache.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheLRU(t *testing.T) {
+	ctx := context.Background()
+	c := newMemoryCache(time.Hour, 0, 2)
+	c.set(ctx, "a", []byte("A"), 0)
+	c.set(ctx, "b", []byte("B"), 0)
+
+	// Reading a makes b the least recently used entry, so adding c evicts b.
+	if _, ok := c.get(ctx, "a"); !ok {
+		t.Fatal("a is not cached")
+	}
+	c.set(ctx, "c", []byte("C"), 0)
+	if _, ok := c.get(ctx, "b"); ok {
+		t.Error("b is still cached after the cache filled up, want it evicted as the least recently used")
+	}
+	for _, key := range []string{"a", "c"} {
+		if entry, ok := c.get(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		} else if want := strings.ToUpper(key); string(entry.body) != want {
+			t.Errorf("%s = %q, want %q", key, entry.body, want)
+		}
+	}
+
+	// Without a read in between, the oldest entry, a, goes next.
+	c.set(ctx, "d", []byte("D"), 0)
+	if _, ok := c.get(ctx, "a"); ok {
+		t.Error("a is still cached, want it evicted as the least recently used")
+	}
+	if got := c.lru.Len(); got != 2 {
+		t.Errorf("cache holds %d entries, want at most 2", got)
+	}
+}
@@ -0,0 +1,70 @@
+// category.go
+// Apparent temperature categories.
+//
+// With "include_category", get_temperature classifies the apparent
+// temperature into one of five bands for health advisories: freezing, cold,
+// comfortable, hot and dangerous heat. The backend's "feels_like" value is
+// used when it reports one, and the actual temperature otherwise. The bands
+// are split at the four CATEGORY_THRESHOLDS, in Celsius whatever unit the
+// reading is reported in.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// temperatureCategories are the bands, from coldest to hottest; each of
+// CATEGORY_THRESHOLDS is the lowest temperature of the next band.
+var temperatureCategories = []string{"freezing", "cold", "comfortable", "hot", "dangerous heat"}
+
+// defaultCategoryThresholds is the default CATEGORY_THRESHOLDS.
+const defaultCategoryThresholds = "0;10;27;35"
+
+// parseCategoryThresholds parses a semicolon-separated list of the four
+// ascending Celsius thresholds between the categories, e.g. "0;10;27;35".
+func parseCategoryThresholds(raw string) ([]float64, error) {
+	var thresholds []float64
+	for _, part := range strings.Split(raw, ";") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", strings.TrimSpace(part))
+		}
+		if len(thresholds) > 0 && v <= thresholds[len(thresholds)-1] {
+			return nil, fmt.Errorf("thresholds must be in ascending order")
+		}
+		thresholds = append(thresholds, v)
+	}
+	if len(thresholds) != len(temperatureCategories)-1 {
+		return nil, fmt.Errorf("expected %d thresholds, got %d", len(temperatureCategories)-1, len(thresholds))
+	}
+	return thresholds, nil
+}
+
+// temperatureCategory returns the category of an apparent temperature in
+// Celsius.
+func temperatureCategory(celsius float64) string {
+	for i, threshold := range cfg.CategoryThresholds {
+		if celsius < threshold {
+			return temperatureCategories[i]
+		}
+	}
+	return temperatureCategories[len(temperatureCategories)-1]
+}
+
+// category returns the category of the reading, fetched in unit, and the
+// apparent temperature it is based on: the backend's feels-like value if it
+// reported one, and the temperature otherwise. ok is false without either.
+func (r temperatureReading) category(unit string) (category string, apparent float64, feelsLike bool, ok bool) {
+	switch {
+	case r.FeelsLike != nil:
+		apparent, feelsLike = *r.FeelsLike, true
+	case r.Temperature != nil:
+		apparent = *r.Temperature
+	default:
+		return "", 0, false, false
+	}
+	return temperatureCategory(toCelsius(apparent, unit)), apparent, feelsLike, true
+}
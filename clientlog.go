@@ -0,0 +1,154 @@
+// clientlog.go
+// Log messages forwarded to the MCP client.
+//
+// Clients that enable logging with logging/setLevel receive the server's
+// request-scoped log lines as notifications/message, in addition to the file
+// log, so diagnostics are visible without access to the server's machine.
+// Nothing is sent to a client until it has asked for a level.
+//
+// mcp-go v0.26 advertises the logging capability but does not route
+// logging/setLevel, so the transports intercept that request themselves (see
+// interceptSetLevel): the level is recorded for the session and the request
+// is passed on as a ping, whose empty result is exactly what setLevel returns.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// logSeverity orders the MCP (syslog) levels from least to most severe.
+var logSeverity = map[mcp.LoggingLevel]int{
+	mcp.LoggingLevelDebug:     0,
+	mcp.LoggingLevelInfo:      1,
+	mcp.LoggingLevelNotice:    2,
+	mcp.LoggingLevelWarning:   3,
+	mcp.LoggingLevelError:     4,
+	mcp.LoggingLevelCritical:  5,
+	mcp.LoggingLevelAlert:     6,
+	mcp.LoggingLevelEmergency: 7,
+}
+
+// sessionLogLevels holds the level each client session asked for.
+type sessionLogLevels struct {
+	mu     sync.Mutex
+	levels map[string]mcp.LoggingLevel
+}
+
+// clientLogLevels is shared by every transport.
+var clientLogLevels = &sessionLogLevels{levels: make(map[string]mcp.LoggingLevel)}
+
+func (l *sessionLogLevels) set(sessionID string, level mcp.LoggingLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levels[sessionID] = level
+}
+
+func (l *sessionLogLevels) forget(sessionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.levels, sessionID)
+}
+
+// enabled reports whether a message at level should be sent to sessionID.
+func (l *sessionLogLevels) enabled(sessionID string, level mcp.LoggingLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	min, ok := l.levels[sessionID]
+	return ok && logSeverity[level] >= logSeverity[min]
+}
+
+// interceptSetLevel handles a logging/setLevel request in message for
+// sessionID: a valid level is recorded and the message is returned rewritten
+// as a ping with the same id. Any other message is returned unchanged, as is
+// a setLevel with an unknown level, which the server then rejects.
+func interceptSetLevel(sessionID string, message []byte) []byte {
+	var req struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  string          `json:"method"`
+		Params  struct {
+			Level mcp.LoggingLevel `json:"level"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &req); err != nil || req.Method != "logging/setLevel" {
+		return message
+	}
+	if _, ok := logSeverity[req.Params.Level]; !ok {
+		log.Printf("[interceptSetLevel] WARNING: unknown log level %q from session %s", req.Params.Level, sessionID)
+		return message
+	}
+	clientLogLevels.set(sessionID, req.Params.Level)
+	log.Printf("[interceptSetLevel] Session %s set log level %s", sessionID, req.Params.Level)
+	ping, err := json.Marshal(map[string]any{"jsonrpc": req.JSONRPC, "id": req.ID, "method": mcp.MethodPing})
+	if err != nil {
+		return message
+	}
+	return ping
+}
+
+// logLinePattern splits a log line into its "[logger]" prefix and message.
+var logLinePattern = regexp.MustCompile(`(?s)^\[([^\]]+)\] (.*)$`)
+
+// logf writes a line to the file log and forwards it to the client of ctx.
+// The line's PANIC, ERROR or WARNING: marker sets its level; anything else
+// is info.
+func logf(ctx context.Context, format string, args ...any) {
+	msg := logCoordinates(fmt.Sprintf(format, args...))
+	log.Print(msg)
+	level := mcp.LoggingLevelInfo
+	switch {
+	case strings.Contains(msg, "PANIC"):
+		level = mcp.LoggingLevelCritical
+	case strings.Contains(msg, "ERROR"):
+		level = mcp.LoggingLevelError
+	case strings.Contains(msg, "WARNING:"):
+		level = mcp.LoggingLevelWarning
+	}
+	sendClientLog(ctx, level, msg)
+}
+
+// debugf is logf for verbose lines, such as response bodies, which clients
+// only receive at the debug level.
+func debugf(ctx context.Context, format string, args ...any) {
+	msg := logCoordinates(fmt.Sprintf(format, args...))
+	log.Print(msg)
+	sendClientLog(ctx, mcp.LoggingLevelDebug, msg)
+}
+
+// sendClientLog sends msg to the client of ctx if it enabled level.
+func sendClientLog(ctx context.Context, level mcp.LoggingLevel, msg string) {
+	session := server.ClientSessionFromContext(ctx)
+	srv := server.ServerFromContext(ctx)
+	if session == nil || srv == nil || !clientLogLevels.enabled(session.SessionID(), level) {
+		return
+	}
+	logger, data := "", msg
+	if m := logLinePattern.FindStringSubmatch(msg); m != nil {
+		logger, data = m[1], m[2]
+	}
+	params := map[string]any{"level": level, "data": map[string]any{"message": data}}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	// Not logged on failure: that would only recurse into another notification.
+	_ = srv.SendNotificationToClient(ctx, "notifications/message", params)
+}
+
+// logLevelHooks forgets a session's log level when the session ends.
+func logLevelHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		clientLogLevels.forget(session.SessionID())
+	})
+	return hooks
+}
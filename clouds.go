@@ -0,0 +1,98 @@
+// clouds.go
+// The "get_cloud_cover" tool.
+//
+// get_cloud_cover returns the percentage of the sky covered by cloud at a
+// location, queried from the backend's /clouds endpoint, together with a
+// qualitative label (Clear, Partly Cloudy or Overcast).
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newCloudCoverTool defines the "get_cloud_cover" tool.
+func newCloudCoverTool() mcp.Tool {
+	return mcp.NewTool("get_cloud_cover",
+		readOnlyAnnotation("Cloud Cover", true),
+		mcp.WithDescription("Get the current cloud cover percentage and whether the sky is clear, partly cloudy or overcast for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the cloud cover for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		formatOptions("the value as reported"),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// cloudReading is the backend's /clouds response.
+type cloudReading struct {
+	Location   string   `json:"location"`
+	CloudCover *float64 `json:"cloud_cover"`
+}
+
+// cloudResult is the structured result of get_cloud_cover.
+type cloudResult struct {
+	Location   string   `json:"location"`
+	Available  bool     `json:"available"`
+	CloudCover *float64 `json:"cloud_cover,omitempty"`
+	Label      string   `json:"label,omitempty"`
+	Stale      bool     `json:"stale,omitempty"`
+}
+
+// cloudLabel describes a cloud cover percentage the way observers report it
+// in oktas (eighths of the sky): up to 1 okta is clear, 7 or more overcast.
+func cloudLabel(percent float64) string {
+	switch oktas := percent / 12.5; {
+	case oktas < 1.5:
+		return "Clear"
+	case oktas < 6.5:
+		return "Partly Cloudy"
+	default:
+		return "Overcast"
+	}
+}
+
+// cloudCoverHandler handles incoming requests to the "get_cloud_cover" tool.
+func cloudCoverHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[cloudCoverHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var reading cloudReading
+	backend, err := fetchMetricReading(ctx, query, "/clouds", nil, "cloud cover", &reading)
+	if err != nil {
+		return nil, err
+	}
+
+	result := cloudResult{Location: location, Stale: backend.Stale}
+	if reading.CloudCover == nil {
+		text := fmt.Sprintf("Cloud cover for %s: no cloud data is available for this location", query.Label)
+		return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+	}
+	if *reading.CloudCover < 0 || *reading.CloudCover > 100 {
+		return nil, invalidResponsef("backend returned an invalid cloud cover of %g%%", *reading.CloudCover)
+	}
+	cover := format.round(*reading.CloudCover)
+	result.Available = true
+	result.CloudCover = &cover
+	result.Label = cloudLabel(*reading.CloudCover)
+	text := fmt.Sprintf("Cloud cover for %s: %s%% (%s)", query.Label, format.format(cover), result.Label)
+	return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+}
@@ -0,0 +1,714 @@
+// config.go
+// Server configuration.
+//
+// Settings are read from environment variables once at startup so every tool
+// handler shares the same view of how the server is set up.
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultEndpoint is the address of the HTTP temperature service used when
+// TEMPERATURE_API_ENDPOINT is not set. Its scheme comes from BACKEND_SCHEME.
+const defaultEndpoint = "localhost:8080"
+
+// config holds the effective server configuration.
+type config struct {
+	// Endpoint is the base URL of the HTTP temperature service. Tool paths
+	// such as "/temperature" are appended to it.
+	Endpoint string
+	// APIVersion, when set, is inserted between Endpoint and the tool path,
+	// e.g. "v2" for ".../v2/temperature".
+	APIVersion string
+	// APIKey authenticates requests to the temperature service. It is never
+	// logged or reported by server_info.
+	APIKey string
+	// AuthScheme selects how credentials are attached to backend requests:
+	// "query", "header", "basic" or "bearer".
+	AuthScheme string
+	// AuthHeader is the header carrying APIKey for the "header" scheme.
+	AuthHeader string
+	// MaxRedirects is how many backend redirects are followed per request.
+	MaxRedirects int
+	// RedirectHosts are the hosts, besides the endpoint's own, that backend
+	// redirects may lead to. Credentials are re-attached only for these.
+	RedirectHosts []string
+	// Username and Password are the credentials for the "basic" scheme.
+	Username string
+	Password string
+	// Aliases maps lower-cased personal location names (e.g. "home") to the
+	// location or "lat,lon" coordinates they stand for.
+	Aliases map[string]string
+	// AllowedLocations lists the only locations (or aliases) that may be
+	// queried, matched case-insensitively; empty allows any.
+	AllowedLocations []string
+	// UnitTokens maps "metric" and "imperial" to the tokens the backend
+	// expects in its units= parameter, for backends that use other names.
+	UnitTokens map[string]string
+	// UnitSynonyms maps extra lower-case unit names, such as "centigrade",
+	// to normalized units, on top of the built-in names.
+	UnitSynonyms map[string]string
+	// HealthPath is the backend path probed by health_check.
+	HealthPath string
+	// StartupWait is how long startup waits for the backend to become
+	// healthy, polling every StartupWaitInterval; zero does not wait.
+	StartupWait         time.Duration
+	StartupWaitInterval time.Duration
+	// GeocodePath is the backend path that resolves place names to
+	// coordinates; empty leaves names for the backend to resolve itself.
+	GeocodePath string
+	// GeocodeCacheTTL is how long resolved coordinates are cached; zero
+	// disables the geocoding cache.
+	GeocodeCacheTTL time.Duration
+	// MoonPath is the backend path get_moon_phase queries; empty computes
+	// the phase locally.
+	MoonPath string
+	// DefaultLocation is queried when a request names no location.
+	DefaultLocation string
+	// TLSMinVersion is the lowest TLS version accepted from HTTPS backends.
+	TLSMinVersion uint16
+	// HTTP2 lets backend connections negotiate HTTP/2 over TLS, as Go does
+	// by default; false forces HTTP/1.1.
+	HTTP2 bool
+	// StreamThreshold is the response size, in bytes, from which backend
+	// bodies are decoded as they arrive instead of read whole; zero always
+	// reads them whole.
+	StreamThreshold int
+	// Timeout bounds each backend request.
+	Timeout time.Duration
+	// ToolTimeouts overrides Timeout for the backend requests of specific
+	// tools, keyed by tool name. main fills it in once the tools are
+	// registered (see parseToolTimeouts).
+	ToolTimeouts map[string]time.Duration
+	// Transport is how clients connect: "stdio" or "sse".
+	Transport string
+	// ToolsListChanged advertises the tools capability's listChanged flag,
+	// for clients that support dynamic tool discovery.
+	ToolsListChanged bool
+	// SSEAddr is the listen address used by the SSE transport.
+	SSEAddr string
+	// ShutdownGrace is how long in-flight SSE requests may run after a
+	// shutdown signal before they are cancelled; zero cancels them at once.
+	ShutdownGrace time.Duration
+	// SSECompression gzip-compresses the SSE event stream for clients that
+	// accept it.
+	SSECompression bool
+	// AutoUnit makes auto-unit mode the default when a request gives no unit.
+	AutoUnit bool
+	// AutoLocate makes get_temperature geolocate the client by IP address
+	// when a request gives no location.
+	AutoLocate bool
+	// StrictUnits rejects unrecognized units instead of falling back to metric.
+	StrictUnits bool
+	// ReferenceUnit is the unit get_temperature always also reports in its
+	// structured result, whatever the display unit; empty reports none.
+	ReferenceUnit string
+	// CategoryThresholds are the Celsius temperatures at which include_category
+	// moves from one category to the next, in ascending order.
+	CategoryThresholds []float64
+	// SupportedLangs are the language codes accepted for "lang".
+	SupportedLangs []string
+	// DefaultLang is the language asked of the backend when a call gives no
+	// "lang"; empty sends none.
+	DefaultLang string
+	// UnsupportedUnitPolicy decides what happens when a client asks for a unit
+	// the backend cannot serve (Kelvin): "convert" locally or "error".
+	UnsupportedUnitPolicy string
+	// DecimalSeparator separates the integer and fractional parts of numbers
+	// in text output.
+	DecimalSeparator string
+	// UnavailableMessage is shown when the backend has no temperature for a location.
+	UnavailableMessage string
+	// MaxConcurrentCalls caps the number of tool calls handled at once; zero
+	// means no limit.
+	MaxConcurrentCalls int
+	// BatchConcurrency caps the number of get_temperatures_batch items
+	// fetched at once within one call.
+	BatchConcurrency int
+	// BatchTruncateOverflow is the default of get_temperatures_batch's
+	// truncate_overflow: process the first items of an oversized batch
+	// instead of rejecting it.
+	BatchTruncateOverflow bool
+	// MaxOutputChars caps the characters of text in a tool result; zero
+	// leaves results uncapped.
+	MaxOutputChars int
+	// BusyPolicy is what happens to a call over the limit: "queue" waits up
+	// to BusyQueueTimeout for a free slot, "reject" fails it immediately.
+	BusyPolicy       string
+	BusyQueueTimeout time.Duration
+	// RetryMaxAttempts is the number of attempts per backend request,
+	// including the first; 1 disables retries. Retries wait RetryBaseDelay,
+	// doubling up to RetryMaxDelay.
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	// RetryTimeoutFactor multiplies the backend timeout of each retry over
+	// the attempt before it; 1 keeps it the same.
+	RetryTimeoutFactor float64
+	// RetryPost opts POST backend requests into retries; only idempotent
+	// methods are retried otherwise.
+	RetryPost bool
+	// RetryBudget bounds the total time a tool call spends retrying backend
+	// requests; zero leaves retries bounded only by the attempt count.
+	RetryBudget time.Duration
+	// CacheTTL is how long backend responses are cached; zero disables caching.
+	CacheTTL time.Duration
+	// CacheMaxEntries caps the entries of each in-memory cache; the least
+	// recently used entry is evicted when it is full.
+	CacheMaxEntries int
+	// CacheStaleGrace is how long past its TTL a cached response may still be
+	// served when the backend fails; zero disables stale serving.
+	CacheStaleGrace time.Duration
+	// LastKnownGood serves the last successful response for a query when
+	// the backend fails and no stale cache entry is left.
+	LastKnownGood bool
+	// MinRefreshInterval is the least time between two backend fetches of
+	// the same query, even for calls asking for fresh data; zero is no limit.
+	MinRefreshInterval time.Duration
+	// TrackUniqueLocations estimates the number of distinct locations
+	// queried, for server_info.
+	TrackUniqueLocations bool
+	// WarmLocations are fetched into the cache at startup and refreshed
+	// every WarmInterval, which defaults to three quarters of CacheTTL.
+	WarmLocations []string
+	WarmInterval  time.Duration
+	// CacheBackend selects where cached responses live: "memory" or "redis".
+	CacheBackend string
+	// RedisURL is the connection URL of the Redis cache backend.
+	RedisURL string
+	// ForecastMaxDays is the furthest ahead, in days, get_forecast may be
+	// asked for, to match what the backend actually serves.
+	ForecastMaxDays int
+	// BackendForecasts allows "time" arguments in the future, for backends
+	// that answer them with forecasts.
+	BackendForecasts bool
+	// KeepAlive is the TCP keepalive probe interval for backend connections;
+	// negative disables keepalives.
+	KeepAlive time.Duration
+	// LogBodyLimit is the number of bytes of a response body written to the log.
+	LogBodyLimit int
+	// LogCoordPrecision is the number of decimals coordinates are rounded to
+	// in the log.
+	LogCoordPrecision int
+	// StrictResponse validates backend responses against the expected schema.
+	StrictResponse bool
+	// TemperatureField is the dotted path of the temperature in backend
+	// responses, e.g. "main.temp"; TemperaturePath holds its segments.
+	TemperatureField string
+	TemperaturePath  []string
+}
+
+// cfg is the configuration loaded by main before any tool is registered.
+var cfg *config
+
+// loadConfig reads the server configuration from the environment.
+func loadConfig() (*config, error) {
+	c := &config{
+		Endpoint:              envString("TEMPERATURE_API_ENDPOINT", defaultEndpoint),
+		APIVersion:            strings.Trim(envString("BACKEND_API_VERSION", ""), "/"),
+		HealthPath:            "/" + strings.TrimLeft(envString("HEALTH_PATH", "/health"), "/"),
+		GeocodePath:           envString("GEOCODE_PATH", ""),
+		MoonPath:              envString("MOON_PATH", ""),
+		DefaultLocation:       envString("DEFAULT_LOCATION", ""),
+		Transport:             strings.ToLower(envString("TRANSPORT", "stdio")),
+		SSEAddr:               envString("SSE_ADDR", "localhost:8081"),
+		CacheBackend:          strings.ToLower(envString("CACHE_BACKEND", "memory")),
+		RedisURL:              envString("REDIS_URL", ""),
+		BusyPolicy:            strings.ToLower(envString("BUSY_POLICY", "queue")),
+		UnsupportedUnitPolicy: strings.ToLower(envString("UNSUPPORTED_UNIT_POLICY", "convert")),
+		DecimalSeparator:      envString("DECIMAL_SEPARATOR", "."),
+		UnavailableMessage: envString("UNAVAILABLE_MESSAGE",
+			"no temperature data is available for this location"),
+	}
+	c.Endpoint = strings.TrimRight(c.Endpoint, "/")
+	// An endpoint given as a bare host gets the configured scheme, so a TLS
+	// backend is never called over plaintext by accident.
+	scheme := strings.ToLower(envString("BACKEND_SCHEME", "http"))
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("invalid BACKEND_SCHEME %q: must be http or https", scheme)
+	}
+	if !strings.Contains(c.Endpoint, "://") {
+		c.Endpoint = scheme + "://" + c.Endpoint
+	}
+	if _, err := url.Parse(c.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid TEMPERATURE_API_ENDPOINT %q: %w", c.Endpoint, err)
+	}
+	if strings.ContainsAny(c.APIVersion, "/?# ") {
+		return nil, fmt.Errorf("invalid BACKEND_API_VERSION %q: must be a single path segment such as v2", c.APIVersion)
+	}
+	if c.Transport != "stdio" && c.Transport != "sse" {
+		return nil, fmt.Errorf("invalid TRANSPORT %q: must be stdio or sse", c.Transport)
+	}
+	apiKey, err := loadSecret("WEATHER_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	c.APIKey = apiKey
+	if err := c.loadAuth(); err != nil {
+		return nil, err
+	}
+	if c.ToolsListChanged, err = envBool("TOOLS_LIST_CHANGED", false); err != nil {
+		return nil, err
+	}
+	if c.SSECompression, err = envBool("SSE_COMPRESSION", false); err != nil {
+		return nil, err
+	}
+	if envString("SHUTDOWN_GRACE", "") != "0" {
+		if c.ShutdownGrace, err = envDuration("SHUTDOWN_GRACE", 10*time.Second); err != nil {
+			return nil, err
+		}
+	}
+	if c.UnsupportedUnitPolicy != "convert" && c.UnsupportedUnitPolicy != "error" {
+		return nil, fmt.Errorf("invalid UNSUPPORTED_UNIT_POLICY %q: must be convert or error", c.UnsupportedUnitPolicy)
+	}
+	if utf8.RuneCountInString(c.DecimalSeparator) != 1 || strings.ContainsAny(c.DecimalSeparator, "0123456789-+") {
+		return nil, fmt.Errorf("invalid DECIMAL_SEPARATOR %q: must be a single non-digit character such as , or .", c.DecimalSeparator)
+	}
+	if c.CacheBackend != "memory" && c.CacheBackend != "redis" {
+		return nil, fmt.Errorf("invalid CACHE_BACKEND %q: must be memory or redis", c.CacheBackend)
+	}
+	aliases, err := parseAliases(os.Getenv("LOCATION_ALIASES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOCATION_ALIASES: %w", err)
+	}
+	c.Aliases = aliases
+	for _, location := range strings.Split(envString("ALLOWED_LOCATIONS", ""), ";") {
+		if location = strings.TrimSpace(location); location != "" {
+			c.AllowedLocations = append(c.AllowedLocations, location)
+		}
+	}
+	if c.UnitSynonyms, err = parseUnitSynonyms(os.Getenv("UNIT_SYNONYMS")); err != nil {
+		return nil, fmt.Errorf("invalid UNIT_SYNONYMS: %w", err)
+	}
+	if c.UnitTokens, err = parseUnitTokens(os.Getenv("BACKEND_UNIT_MAP"), c.UnitSynonyms); err != nil {
+		return nil, fmt.Errorf("invalid BACKEND_UNIT_MAP: %w", err)
+	}
+	minTLS, err := parseTLSVersion(envString("BACKEND_TLS_MIN_VERSION", "1.2"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BACKEND_TLS_MIN_VERSION: %w", err)
+	}
+	c.TLSMinVersion = minTLS
+	if c.HTTP2, err = envBool("BACKEND_HTTP2", true); err != nil {
+		return nil, err
+	}
+	if c.StreamThreshold, err = envInt("BACKEND_STREAM_THRESHOLD", defaultStreamThreshold); err != nil {
+		return nil, err
+	}
+	if c.Timeout, err = envDuration("BACKEND_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if c.MaxRedirects, err = envInt("BACKEND_MAX_REDIRECTS", 10); err != nil {
+		return nil, err
+	}
+	for _, host := range strings.Split(envString("BACKEND_REDIRECT_HOSTS", ""), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			c.RedirectHosts = append(c.RedirectHosts, host)
+		}
+	}
+	if c.MaxConcurrentCalls, err = envInt("MAX_CONCURRENT_CALLS", 0); err != nil {
+		return nil, err
+	}
+	if c.BatchConcurrency, err = envInt("BATCH_CONCURRENCY", defaultBatchConcurrency); err != nil {
+		return nil, err
+	}
+	if c.BatchConcurrency < 1 {
+		return nil, fmt.Errorf("invalid BATCH_CONCURRENCY %d: must be at least 1", c.BatchConcurrency)
+	}
+	if c.BatchTruncateOverflow, err = envBool("BATCH_TRUNCATE_OVERFLOW", false); err != nil {
+		return nil, err
+	}
+	if c.MaxOutputChars, err = envInt("MAX_OUTPUT_CHARS", 0); err != nil {
+		return nil, err
+	}
+	if c.MaxOutputChars > 0 && c.MaxOutputChars < minOutputLimit {
+		return nil, fmt.Errorf("invalid MAX_OUTPUT_CHARS %d: must be 0 (no limit) or at least %d", c.MaxOutputChars, minOutputLimit)
+	}
+	if c.BusyPolicy != "queue" && c.BusyPolicy != "reject" {
+		return nil, fmt.Errorf("invalid BUSY_POLICY %q: must be queue or reject", c.BusyPolicy)
+	}
+	if c.BusyQueueTimeout, err = envDuration("BUSY_QUEUE_TIMEOUT", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if err := c.loadRetryPolicy(); err != nil {
+		return nil, err
+	}
+	if envString("RETRY_BUDGET", "") != "0" {
+		if c.RetryBudget, err = envDuration("RETRY_BUDGET", 20*time.Second); err != nil {
+			return nil, err
+		}
+	}
+	if envString("CACHE_TTL", "") != "0" {
+		if c.CacheTTL, err = envDuration("CACHE_TTL", time.Minute); err != nil {
+			return nil, err
+		}
+	}
+	if c.CacheMaxEntries, err = envInt("CACHE_MAX_ENTRIES", defaultCacheMaxEntries); err != nil {
+		return nil, err
+	}
+	if c.CacheMaxEntries < 1 {
+		return nil, fmt.Errorf("invalid CACHE_MAX_ENTRIES %d: must be at least 1", c.CacheMaxEntries)
+	}
+	if c.GeocodePath != "" {
+		c.GeocodePath = "/" + strings.TrimLeft(c.GeocodePath, "/")
+	}
+	if c.MoonPath != "" {
+		c.MoonPath = "/" + strings.TrimLeft(c.MoonPath, "/")
+	}
+	if v := envString("STARTUP_WAIT", ""); v != "" && v != "0" {
+		if c.StartupWait, err = envDuration("STARTUP_WAIT", 0); err != nil {
+			return nil, err
+		}
+	}
+	if c.StartupWaitInterval, err = envDuration("STARTUP_WAIT_INTERVAL", time.Second); err != nil {
+		return nil, err
+	}
+	if envString("GEOCODE_CACHE_TTL", "") != "0" {
+		if c.GeocodeCacheTTL, err = envDuration("GEOCODE_CACHE_TTL", 24*time.Hour); err != nil {
+			return nil, err
+		}
+	}
+	for _, location := range strings.Split(envString("CACHE_WARM_LOCATIONS", ""), ";") {
+		if location = strings.TrimSpace(location); location != "" {
+			c.WarmLocations = append(c.WarmLocations, location)
+		}
+	}
+	if c.WarmInterval, err = envDuration("CACHE_WARM_INTERVAL", c.CacheTTL*3/4); err != nil {
+		return nil, err
+	}
+	if len(c.WarmLocations) > 0 && c.CacheTTL > 0 && c.WarmInterval >= c.CacheTTL {
+		return nil, fmt.Errorf("invalid CACHE_WARM_INTERVAL %s: must be shorter than CACHE_TTL %s, or warmed entries expire between refreshes", c.WarmInterval, c.CacheTTL)
+	}
+	if v := envString("CACHE_STALE_GRACE", ""); v != "" && v != "0" {
+		if c.CacheStaleGrace, err = envDuration("CACHE_STALE_GRACE", 0); err != nil {
+			return nil, err
+		}
+	}
+	if c.LastKnownGood, err = envBool("LAST_KNOWN_GOOD", false); err != nil {
+		return nil, err
+	}
+	if v := envString("MIN_REFRESH_INTERVAL", ""); v != "" && v != "0" {
+		if c.MinRefreshInterval, err = envDuration("MIN_REFRESH_INTERVAL", 0); err != nil {
+			return nil, err
+		}
+	}
+	if c.TrackUniqueLocations, err = envBool("TRACK_UNIQUE_LOCATIONS", true); err != nil {
+		return nil, err
+	}
+	if c.AutoUnit, err = envBool("AUTO_UNIT", false); err != nil {
+		return nil, err
+	}
+	if v := envString("REFERENCE_UNIT", ""); v != "" {
+		unit, ok := resolveUnit(v, c.UnitSynonyms)
+		if !ok {
+			return nil, fmt.Errorf("invalid REFERENCE_UNIT %q: must be one of %s", v, validUnits)
+		}
+		c.ReferenceUnit = unit
+	}
+	if c.CategoryThresholds, err = parseCategoryThresholds(envString("CATEGORY_THRESHOLDS", defaultCategoryThresholds)); err != nil {
+		return nil, fmt.Errorf("invalid CATEGORY_THRESHOLDS: %w", err)
+	}
+	if c.SupportedLangs, err = parseSupportedLangs(envString("SUPPORTED_LANGS", defaultSupportedLangs)); err != nil {
+		return nil, fmt.Errorf("invalid SUPPORTED_LANGS: %w", err)
+	}
+	if v := envString("DEFAULT_LANG", ""); v != "" {
+		lang, ok := matchLang(v, c.SupportedLangs)
+		if !ok {
+			return nil, fmt.Errorf("invalid DEFAULT_LANG %q: must be one of SUPPORTED_LANGS", v)
+		}
+		c.DefaultLang = lang
+	}
+	if c.AutoLocate, err = envBool("AUTO_LOCATE", false); err != nil {
+		return nil, err
+	}
+	if c.StrictUnits, err = envBool("STRICT_UNITS", false); err != nil {
+		return nil, err
+	}
+	c.KeepAlive = -1
+	if envString("BACKEND_KEEPALIVE", "") != "0" {
+		if c.KeepAlive, err = envDuration("BACKEND_KEEPALIVE", 30*time.Second); err != nil {
+			return nil, err
+		}
+	}
+	if c.LogBodyLimit, err = envInt("LOG_BODY_LIMIT", 512); err != nil {
+		return nil, err
+	}
+	if c.LogCoordPrecision, err = envInt("LOG_COORD_PRECISION", 2); err != nil {
+		return nil, err
+	}
+	if c.BackendForecasts, err = envBool("BACKEND_SUPPORTS_FORECASTS", false); err != nil {
+		return nil, err
+	}
+	if c.ForecastMaxDays, err = envInt("FORECAST_MAX_DAYS", defaultForecastMaxDays); err != nil {
+		return nil, err
+	}
+	if c.ForecastMaxDays < 1 {
+		return nil, fmt.Errorf("invalid FORECAST_MAX_DAYS %d: must be at least 1", c.ForecastMaxDays)
+	}
+	if c.StrictResponse, err = envBool("STRICT_RESPONSE", false); err != nil {
+		return nil, err
+	}
+	c.TemperatureField = strings.TrimSpace(envString("TEMPERATURE_FIELD", defaultTemperatureField))
+	if c.TemperaturePath, err = parseFieldPath(c.TemperatureField); err != nil {
+		return nil, fmt.Errorf("invalid TEMPERATURE_FIELD: %w", err)
+	}
+	return c, nil
+}
+
+// parseTLSVersion converts a version string such as "1.2" to its crypto/tls constant.
+func parseTLSVersion(v string) (uint16, error) {
+	switch v {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q (want 1.0, 1.1, 1.2 or 1.3)", v)
+	}
+}
+
+// loadSecret returns the secret held in the environment variable name. The
+// file named by name+"_FILE" (as mounted by Docker or Kubernetes secrets),
+// when set, takes precedence over the inline value.
+func loadSecret(name string) (string, error) {
+	if path := envString(name+"_FILE", ""); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s_FILE: %w", name, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return os.Getenv(name), nil
+}
+
+// parseAliases parses a semicolon-separated list of name=location pairs, e.g.
+// "home=Chapel Hill;work=35.91,-79.05". Names are matched case-insensitively.
+func parseAliases(raw string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		name, target = strings.TrimSpace(name), strings.TrimSpace(target)
+		if !ok || name == "" || target == "" {
+			return nil, fmt.Errorf("entry %q must be of the form name=location", entry)
+		}
+		aliases[strings.ToLower(name)] = target
+	}
+	return aliases, nil
+}
+
+// parseUnitTokens parses a semicolon-separated list of unit=token pairs, e.g.
+// "metric=SI;imperial=US". Units may be given by any name parseUnit accepts,
+// including synonyms.
+func parseUnitTokens(raw string, synonyms map[string]string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(entry, "=")
+		token = strings.TrimSpace(token)
+		if !ok || token == "" {
+			return nil, fmt.Errorf("entry %q must be of the form unit=token", entry)
+		}
+		unit, ok := resolveUnit(name, synonyms)
+		if !ok || unit == "kelvin" {
+			return nil, fmt.Errorf("entry %q: unit must be metric or imperial", entry)
+		}
+		tokens[unit] = token
+	}
+	return tokens, nil
+}
+
+// resolveAlias returns the configured location for name, if name is an alias.
+func (c *config) resolveAlias(name string) (string, bool) {
+	target, ok := c.Aliases[strings.ToLower(strings.TrimSpace(name))]
+	return target, ok
+}
+
+// locationAllowed reports whether any of names, matched case-insensitively,
+// is in ALLOWED_LOCATIONS, or whether every location is allowed.
+func (c *config) locationAllowed(names ...string) bool {
+	if len(c.AllowedLocations) == 0 {
+		return true
+	}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if slices.ContainsFunc(c.AllowedLocations, func(allowed string) bool { return strings.EqualFold(allowed, name) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// envString returns the trimmed value of the environment variable key, or def
+// when it is unset or empty.
+func envString(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+// parseToolTimeouts collects TIMEOUT_<TOOL> variables from environ, e.g.
+// TIMEOUT_GET_FORECAST=20s sets the timeout of the "get_forecast" tool.
+// Only the names of tools are honoured: other TIMEOUT_ variables, such as a
+// TIMEOUT_SECONDS meant for another program, are logged and ignored.
+func parseToolTimeouts(environ []string, tools map[string]bool) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, "TIMEOUT_")
+		if !ok || name == "" {
+			continue
+		}
+		if !tools[strings.ToLower(name)] {
+			log.Printf("[main] WARNING: ignoring %s: there is no tool named %q", key, strings.ToLower(name))
+			continue
+		}
+		d, err := envDuration(key, 0)
+		if err != nil {
+			return nil, err
+		}
+		if d > 0 {
+			timeouts[strings.ToLower(name)] = d
+		}
+	}
+	return timeouts, nil
+}
+
+// timeoutFor returns the backend request timeout for the named tool.
+func (c *config) timeoutFor(tool string) time.Duration {
+	if d, ok := c.ToolTimeouts[tool]; ok {
+		return d
+	}
+	return c.Timeout
+}
+
+// envInt parses the environment variable key as a non-negative integer,
+// returning def when it is unset.
+func envInt(key string, def int) (int, error) {
+	v := envString(key, "")
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", key, v)
+	}
+	return n, nil
+}
+
+// envBool parses the environment variable key as a boolean ("true", "1",
+// "false", ...), returning def when it is unset.
+func envBool(key string, def bool) (bool, error) {
+	v := envString(key, "")
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be true or false", key, v)
+	}
+	return b, nil
+}
+
+// envDuration parses the environment variable key as a positive duration
+// (e.g. "10s"), returning def when it is unset.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	v := envString(key, "")
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 10s", key, v)
+	}
+	return d, nil
+}
+
+// loadRetryPolicy reads the RETRY_* settings. Values that parse but make no
+// sense are clamped, with a warning, rather than refused: no attempts at all
+// means one, very many means maxRetryAttempts, and a base delay longer than
+// the maximum is cut down to it, and a timeout factor is kept between 1 and
+// maxRetryTimeoutFactor.
+func (c *config) loadRetryPolicy() error {
+	var err error
+	if c.RetryMaxAttempts, err = envInt("RETRY_MAX_ATTEMPTS", defaultRetryMaxAttempts); err != nil {
+		return err
+	}
+	switch {
+	case c.RetryMaxAttempts < 1:
+		log.Printf("[loadConfig] WARNING: RETRY_MAX_ATTEMPTS %d is less than 1, using 1 (no retries)", c.RetryMaxAttempts)
+		c.RetryMaxAttempts = 1
+	case c.RetryMaxAttempts > maxRetryAttempts:
+		log.Printf("[loadConfig] WARNING: RETRY_MAX_ATTEMPTS %d is more than %d, using %d", c.RetryMaxAttempts, maxRetryAttempts, maxRetryAttempts)
+		c.RetryMaxAttempts = maxRetryAttempts
+	}
+	if c.RetryBaseDelay, err = envDuration("RETRY_BASE_DELAY", defaultRetryBaseDelay); err != nil {
+		return err
+	}
+	if c.RetryMaxDelay, err = envDuration("RETRY_MAX_DELAY", defaultRetryMaxDelay); err != nil {
+		return err
+	}
+	if c.RetryBaseDelay > c.RetryMaxDelay {
+		log.Printf("[loadConfig] WARNING: RETRY_BASE_DELAY %s is longer than RETRY_MAX_DELAY %s, using %s", c.RetryBaseDelay, c.RetryMaxDelay, c.RetryMaxDelay)
+		c.RetryBaseDelay = c.RetryMaxDelay
+	}
+	c.RetryTimeoutFactor = defaultRetryTimeoutFactor
+	if v := envString("RETRY_TIMEOUT_FACTOR", ""); v != "" {
+		if c.RetryTimeoutFactor, err = strconv.ParseFloat(v, 64); err != nil || math.IsNaN(c.RetryTimeoutFactor) || math.IsInf(c.RetryTimeoutFactor, 0) {
+			return fmt.Errorf("invalid RETRY_TIMEOUT_FACTOR %q: must be a number such as 2", v)
+		}
+		if c.RetryTimeoutFactor < 1 {
+			log.Printf("[loadConfig] WARNING: RETRY_TIMEOUT_FACTOR %g is less than 1, using 1 (no escalation)", c.RetryTimeoutFactor)
+			c.RetryTimeoutFactor = 1
+		}
+		if c.RetryTimeoutFactor > maxRetryTimeoutFactor {
+			log.Printf("[loadConfig] WARNING: RETRY_TIMEOUT_FACTOR %g is more than %g, using %g", c.RetryTimeoutFactor, maxRetryTimeoutFactor, maxRetryTimeoutFactor)
+			c.RetryTimeoutFactor = maxRetryTimeoutFactor
+		}
+	}
+	if c.RetryPost, err = envBool("RETRY_POST", false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// apiPath returns the backend path for a tool path such as "/temperature",
+// prefixed with the API version when one is configured.
+func (c *config) apiPath(path string) string {
+	if c.APIVersion == "" {
+		return path
+	}
+	return "/" + c.APIVersion + path
+}
+
+// backendHost returns the host (and port) of the configured endpoint without
+// its scheme, path or query, so it can be shown without leaking credentials.
+func (c *config) backendHost() string {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
@@ -0,0 +1,37 @@
+// config_test.go
+// Tests of the configuration parsing.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToolTimeouts(t *testing.T) {
+	tools := map[string]bool{"get_forecast": true, "get_sun_times": true}
+	environ := []string{
+		"TIMEOUT_GET_FORECAST=20s",
+		// Unrelated variables of other programs are ignored, valid or not.
+		"TIMEOUT_SECONDS=30",
+		"TIMEOUT_=5s",
+	}
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		t.Setenv(key, value)
+	}
+	timeouts, err := parseToolTimeouts(environ, tools)
+	if err != nil {
+		t.Fatalf("parseToolTimeouts: %v", err)
+	}
+	if len(timeouts) != 1 || timeouts["get_forecast"] != 20*time.Second {
+		t.Errorf("parseToolTimeouts = %v, want only get_forecast: 20s", timeouts)
+	}
+
+	// A tool's own timeout must still be valid.
+	t.Setenv("TIMEOUT_GET_SUN_TIMES", "soon")
+	if _, err := parseToolTimeouts(append(environ, "TIMEOUT_GET_SUN_TIMES=soon"), tools); err == nil {
+		t.Error("parseToolTimeouts accepted TIMEOUT_GET_SUN_TIMES=soon")
+	}
+}
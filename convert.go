@@ -0,0 +1,94 @@
+// convert.go
+// The "convert_temperature" tool.
+//
+// convert_temperature converts a value between Celsius, Fahrenheit and Kelvin
+// locally, without calling the backend. Every conversion goes through Celsius
+// and is rounded once, at the end.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultConvertPrecision is the number of decimals used when the request
+// does not ask for a precision, so conversions don't show float noise such
+// as 98.60000000000001.
+const defaultConvertPrecision = 2
+
+// absoluteZeroCelsius is the lowest possible temperature.
+const absoluteZeroCelsius = -273.15
+
+// newConvertTool defines the "convert_temperature" tool.
+func newConvertTool() mcp.Tool {
+	return mcp.NewTool("convert_temperature",
+		readOnlyAnnotation("Convert Temperature", false),
+		mcp.WithDescription("Convert a temperature value between Celsius, Fahrenheit and Kelvin"),
+		mcp.WithNumber("value",
+			mcp.Required(),
+			mcp.Description("The temperature to convert"),
+		),
+		mcp.WithString("from",
+			mcp.Required(),
+			mcp.Description("Unit of value: celsius (c), fahrenheit (f) or kelvin (k)"),
+		),
+		mcp.WithString("to",
+			mcp.Required(),
+			mcp.Description("Unit to convert to: celsius (c), fahrenheit (f) or kelvin (k)"),
+		),
+		mcp.WithNumber("precision",
+			mcp.Description(fmt.Sprintf("Number of decimals to show (0-6); defaults to %d", defaultConvertPrecision)),
+		),
+		mcp.WithString("rounding",
+			mcp.Description("How to round to the precision: round (half to even, the default), floor or ceil"),
+			mcp.Enum("round", "floor", "ceil"),
+		),
+		prettyOption(),
+	)
+}
+
+// conversionResult is the structured result of convert_temperature.
+type conversionResult struct {
+	Value     float64 `json:"value"`
+	From      string  `json:"from"`
+	Converted float64 `json:"converted"`
+	To        string  `json:"to"`
+}
+
+// convertHandler handles incoming requests to the "convert_temperature" tool.
+func convertHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	value, ok := request.Params.Arguments["value"].(float64)
+	if !ok {
+		return nil, invalidArgumentf("value must be a number")
+	}
+	from, ok := parseUnit(mcp.ParseString(request, "from", ""))
+	if !ok {
+		return nil, invalidArgumentf("unknown from unit %q (want celsius, fahrenheit or kelvin)", mcp.ParseString(request, "from", ""))
+	}
+	to, ok := parseUnit(mcp.ParseString(request, "to", ""))
+	if !ok {
+		return nil, invalidArgumentf("unknown to unit %q (want celsius, fahrenheit or kelvin)", mcp.ParseString(request, "to", ""))
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	if format.Precision < 0 {
+		format.Precision = defaultConvertPrecision
+	}
+
+	celsius := toCelsius(value, from)
+	// Allow for float error, so -459.67°F itself is accepted.
+	if celsius < absoluteZeroCelsius-1e-9 {
+		return nil, invalidArgumentf("%s is below absolute zero", formatTemperature(value, from, numberFormat{Precision: -1}))
+	}
+	converted := format.round(fromCelsius(celsius, to))
+	result := conversionResult{Value: value, From: from, Converted: converted, To: to}
+	text := fmt.Sprintf("%s = %s",
+		formatTemperature(value, from, numberFormat{Precision: -1}),
+		formatTemperature(converted, to, format))
+	return newStructuredResult(text, result, prettyArg(request))
+}
@@ -0,0 +1,289 @@
+// describe.go
+// The "describe_weather" tool.
+//
+// describe_weather is for conversational agents and voice assistants: it
+// fetches the temperature, wind and precipitation of a location concurrently
+// and composes a paragraph ready to be spoken, e.g. "It's a mild 18°C in
+// Lisbon with light winds and clear skies." Whatever the backend does not
+// report, or fails to fetch, is left out of the narrative; only the
+// temperature itself is required.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// feelsLikeThreshold is how far, in Celsius degrees, the apparent
+// temperature must be from the actual one to be worth mentioning.
+const feelsLikeThreshold = 3
+
+// precipitationMentionChance is the lowest chance of precipitation, in
+// percent, that the narrative mentions.
+const precipitationMentionChance = 20
+
+// temperatureWords describe temperatures, from coldest to hottest; each
+// word applies below its Celsius limit, the last one above all of them.
+var temperatureWords = []struct {
+	Below float64
+	Word  string
+}{
+	{0, "freezing"},
+	{8, "cold"},
+	{14, "cool"},
+	{22, "mild"},
+	{28, "warm"},
+	{35, "hot"},
+	{math.Inf(1), "scorching"},
+}
+
+// windWords describe wind speeds in m/s, after the Beaufort scale; each
+// applies below its limit.
+var windWords = []struct {
+	Below float64
+	Words string
+}{
+	{0.5, "calm air"},
+	{3.4, "light winds"},
+	{8, "a moderate breeze"},
+	{13.9, "strong winds"},
+	{20.8, "gale-force winds"},
+	{math.Inf(1), "storm-force winds"},
+}
+
+// conditionPhrases are how the narrative names condition categories (see
+// conditionKey). Wind is described from the wind reading instead, and
+// unknown codes are used as written.
+var conditionPhrases = map[string]string{
+	"clear":        "clear skies",
+	"sunny":        "sunny skies",
+	"partlycloudy": "partly cloudy skies",
+	"mostlycloudy": "mostly cloudy skies",
+	"cloudy":       "cloudy skies",
+	"overcast":     "overcast skies",
+	"thunderstorm": "thunderstorms",
+	"storm":        "storms",
+	"wind":         "",
+	"windy":        "",
+}
+
+// newDescribeWeatherTool defines the "describe_weather" tool.
+func newDescribeWeatherTool() mcp.Tool {
+	return mcp.NewTool("describe_weather",
+		readOnlyAnnotation("Weather Description", true),
+		mcp.WithDescription("Describe the current weather for a given location in a short natural-language paragraph, ready to be read out or spoken"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to describe the weather for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system: metric (celsius), imperial (fahrenheit) or kelvin; defaults to metric"),
+		),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// weatherDescription is the structured result of describe_weather.
+type weatherDescription struct {
+	Location    string   `json:"location"`
+	Narrative   string   `json:"narrative"`
+	Unit        string   `json:"unit"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	FeelsLike   *float64 `json:"feels_like,omitempty"`
+	Conditions  string   `json:"conditions,omitempty"`
+	// WindSpeed is in m/s, whatever the unit.
+	WindSpeed *float64 `json:"wind_speed_ms,omitempty"`
+	// PrecipitationChance is in percent.
+	PrecipitationChance *float64 `json:"precipitation_chance,omitempty"`
+	// Omitted lists the metrics left out because they were unavailable:
+	// "wind" and "precipitation".
+	Omitted []string `json:"omitted,omitempty"`
+	Stale   bool     `json:"stale,omitempty"`
+}
+
+// describeWeatherHandler handles incoming requests to the "describe_weather" tool.
+func describeWeatherHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[describeWeatherHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := normalizeUnit(mcp.ParseString(request, "unit", ""))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnitSupported(unit); err != nil {
+		return nil, err
+	}
+
+	// Fetch the three readings at once; only the temperature must succeed.
+	// A panic in one of them fails just that reading, as in a combined
+	// get_temperature query, since the handler's recover cannot reach it.
+	var (
+		wg                sync.WaitGroup
+		tempResp          backendResponse
+		reading           temperatureReading
+		tempErr           error
+		wind              windReading
+		precipitation     precipitationReading
+		windStale, windOK bool
+		precStale, precOK bool
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		defer recoverReading(ctx, "/temperature", func() { tempErr = errors.New("internal error") })
+		tempResp, reading, tempErr = fetchReading(ctx, query, unit, temperatureOptions{Unit: unit})
+	}()
+	go func() {
+		defer wg.Done()
+		defer recoverReading(ctx, "/wind", func() { windStale, windOK = false, false })
+		windStale, windOK = fetchMetric(ctx, query, "/wind", &wind)
+	}()
+	go func() {
+		defer wg.Done()
+		defer recoverReading(ctx, "/precip", func() { precStale, precOK = false, false })
+		precStale, precOK = fetchMetric(ctx, query, "/precip", &precipitation)
+	}()
+	wg.Wait()
+	if tempErr != nil {
+		return nil, tempErr
+	}
+
+	result := weatherDescription{Location: location, Unit: unit, Stale: tempResp.Stale}
+	if windOK && wind.Speed != nil && *wind.Speed >= 0 {
+		result.WindSpeed = wind.Speed
+		result.Stale = result.Stale || windStale
+	} else {
+		result.Omitted = append(result.Omitted, "wind")
+	}
+	if p := precipitation.Probability; precOK && p != nil && *p >= 0 && *p <= 100 {
+		result.PrecipitationChance = p
+		result.Stale = result.Stale || precStale
+	} else {
+		result.Omitted = append(result.Omitted, "precipitation")
+	}
+	if reading.hasData() {
+		result.Temperature, result.FeelsLike, result.Conditions = reading.Temperature, reading.FeelsLike, reading.Conditions
+	}
+	result.Narrative = weatherNarrative(query.Label, unit, reading, result.WindSpeed, result.PrecipitationChance)
+	text := result.Narrative
+	if result.Stale {
+		text += " (Some of this was cached during a backend outage and may be out of date.)"
+	}
+	return newStructuredResult(text, result, prettyArg(request))
+}
+
+// recoverReading recovers a panic while fetching path, logging it and
+// calling fail to mark that reading as failed. It must be deferred directly.
+func recoverReading(ctx context.Context, path string, fail func()) {
+	if r := recover(); r != nil {
+		logf(ctx, "[describeWeatherHandler] PANIC fetching %s: %v\n%s", path, r, debug.Stack())
+		fail()
+	}
+}
+
+// fetchMetric fetches path for query into v, a backend reading, and reports
+// whether the response was stale. ok is false when it could not be fetched
+// or parsed, which is logged rather than failing the description.
+func fetchMetric(ctx context.Context, query locationQuery, path string, v any) (stale, ok bool) {
+	backend, err := fetchMetricReading(ctx, query, path, nil, strings.TrimPrefix(path, "/"), v)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			logf(ctx, "[describeWeatherHandler] WARNING: leaving out %s: %v", path, err)
+		}
+		return false, false
+	}
+	return backend.Stale, true
+}
+
+// weatherNarrative composes the paragraph describing reading, the wind
+// speed in m/s and the chance of precipitation, leaving out whatever is nil
+// or missing from reading.
+func weatherNarrative(label, unit string, reading temperatureReading, windSpeed, precipitationChance *float64) string {
+	whole := numberFormat{Precision: 0}
+	var details []string
+	if windSpeed != nil {
+		details = append(details, windDescription(*windSpeed))
+	}
+	if phrase := conditionPhrase(reading.Conditions); phrase != "" {
+		details = append(details, phrase)
+	}
+
+	var b strings.Builder
+	if reading.hasData() {
+		temperature := *reading.Temperature
+		celsius := toCelsius(temperature, unit)
+		fmt.Fprintf(&b, "It's a %s %s in %s", temperatureWord(celsius), formatTemperature(whole.round(temperature), unit, whole), label)
+		if len(details) > 0 {
+			fmt.Fprintf(&b, " with %s", joinList(details))
+		}
+		if f := reading.FeelsLike; f != nil && math.Abs(toCelsius(*f, unit)-celsius) >= feelsLikeThreshold {
+			fmt.Fprintf(&b, ", though it feels like %s", formatTemperature(whole.round(*f), unit, whole))
+		}
+		b.WriteString(".")
+	} else {
+		fmt.Fprintf(&b, "There's no temperature reading for %s right now", label)
+		if len(details) > 0 {
+			fmt.Fprintf(&b, ", but expect %s", joinList(details))
+		}
+		b.WriteString(".")
+	}
+	if p := precipitationChance; p != nil && *p >= precipitationMentionChance {
+		fmt.Fprintf(&b, " There's a %s%% chance of precipitation.", whole.format(whole.round(*p)))
+	}
+	return b.String()
+}
+
+// temperatureWord describes a temperature in Celsius.
+func temperatureWord(celsius float64) string {
+	for _, w := range temperatureWords {
+		if celsius < w.Below {
+			return w.Word
+		}
+	}
+	return temperatureWords[len(temperatureWords)-1].Word
+}
+
+// windDescription describes a wind speed in m/s.
+func windDescription(speed float64) string {
+	for _, w := range windWords {
+		if speed < w.Below {
+			return w.Words
+		}
+	}
+	return windWords[len(windWords)-1].Words
+}
+
+// conditionPhrase returns how the narrative names a conditions code: a
+// known phrase, or the code itself, lower-cased and with separators as
+// spaces.
+func conditionPhrase(conditions string) string {
+	if phrase, ok := conditionPhrases[conditionKey(conditions)]; ok {
+		return phrase
+	}
+	return strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(strings.TrimSpace(conditions)))
+}
+
+// joinList joins items as a spoken list: "a", "a and b", "a, b and c".
+func joinList(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
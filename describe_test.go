@@ -0,0 +1,45 @@
+// describe_test.go
+// Tests of the describe_weather tool.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// panickingCache panics on lookups of keys under path and caches nothing
+// else, standing in for a bug in the handler's own goroutines.
+type panickingCache struct {
+	noCache
+	path string
+}
+
+func (c panickingCache) get(ctx context.Context, key string) (cacheEntry, bool) {
+	if strings.HasPrefix(key, c.path+"?") {
+		panic("boom")
+	}
+	return c.noCache.get(ctx, key)
+}
+
+// TestDescribeWeatherPanic checks that a panic while fetching one reading,
+// in its own goroutine, fails only that reading.
+func TestDescribeWeatherPanic(t *testing.T) {
+	setupBackend(t, jsonHandler(`{"temperature":18,"speed":3,"probability":10}`), nil)
+
+	cache = panickingCache{path: "/wind"}
+	result, err := describeWeatherHandler(context.Background(), toolRequest(map[string]any{"location": "Lisbon"}))
+	if err != nil {
+		t.Fatalf("describeWeatherHandler: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "18°C") || strings.Contains(text, "wind") {
+		t.Errorf("text = %q, want the temperature without the wind", text)
+	}
+
+	cache = panickingCache{path: "/temperature"}
+	_, err = describeWeatherHandler(context.Background(), toolRequest(map[string]any{"location": "Porto"}))
+	if err == nil || err.Error() != "internal error" {
+		t.Errorf("describeWeatherHandler error = %v, want an internal error", err)
+	}
+}
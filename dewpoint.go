@@ -0,0 +1,38 @@
+// dewpoint.go
+// Dew point reporting.
+//
+// The backend's own dew point is used when it reports one. Otherwise the dew
+// point is derived from the temperature and relative humidity with the
+// Magnus formula, which is accurate to a fraction of a degree for everyday
+// weather (roughly -45°C to 60°C).
+
+package main
+
+import "math"
+
+// Magnus coefficients (Alduchov and Eskridge, 1996) over water.
+const (
+	magnusB = 17.62
+	magnusC = 243.12 // °C
+)
+
+// dewPointCelsius returns the dew point for a temperature in Celsius at the
+// given relative humidity, in percent.
+func dewPointCelsius(tempC, humidity float64) float64 {
+	gamma := math.Log(humidity/100) + magnusB*tempC/(magnusC+tempC)
+	return magnusC * gamma / (magnusB - gamma)
+}
+
+// dewPoint returns the reading's dew point in unit, the unit the reading was
+// fetched in. ok is false when the backend reported no dew point and there is
+// no temperature and usable humidity to compute it from.
+func (r temperatureReading) dewPoint(unit string) (float64, bool) {
+	if r.DewPoint != nil {
+		return *r.DewPoint, true
+	}
+	if r.Temperature == nil || r.Humidity == nil || *r.Humidity <= 0 || *r.Humidity > 100 {
+		return 0, false
+	}
+	c := dewPointCelsius(toCelsius(*r.Temperature, unit), *r.Humidity)
+	return fromCelsius(c, unit), true
+}
@@ -0,0 +1,54 @@
+// emoji.go
+// Weather condition emoji.
+//
+// With "include_emoji", get_temperature prefixes its output with an emoji for
+// the backend's "conditions" code (e.g. "rain" → 🌧️), which reads well in chat
+// UIs. Codes are matched by category, case-insensitively and ignoring
+// separators, so "Partly Cloudy", "partly-cloudy" and "partly_cloudy" agree.
+// Unknown or missing conditions get no emoji.
+
+package main
+
+import "strings"
+
+// conditionEmoji maps condition categories to their emoji.
+var conditionEmoji = map[string]string{
+	"clear":        "☀️",
+	"sunny":        "☀️",
+	"partlycloudy": "⛅",
+	"mostlycloudy": "🌥️",
+	"cloudy":       "☁️",
+	"overcast":     "☁️",
+	"fog":          "🌫️",
+	"mist":         "🌫️",
+	"haze":         "🌫️",
+	"drizzle":      "🌦️",
+	"showers":      "🌦️",
+	"rain":         "🌧️",
+	"thunderstorm": "⛈️",
+	"storm":        "⛈️",
+	"sleet":        "🌨️",
+	"hail":         "🌨️",
+	"snow":         "❄️",
+	"wind":         "💨",
+	"windy":        "💨",
+}
+
+// conditionKey returns the category of a conditions code: lower-cased,
+// without separators.
+func conditionKey(conditions string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(conditions)))
+}
+
+// emojiFor returns the emoji for a conditions code, or "" when it is unknown.
+func emojiFor(conditions string) string {
+	return conditionEmoji[conditionKey(conditions)]
+}
+
+// emojiPrefix returns emoji followed by a space, or "" for no emoji.
+func emojiPrefix(emoji string) string {
+	if emoji == "" {
+		return ""
+	}
+	return emoji + " "
+}
@@ -0,0 +1,126 @@
+// envelope.go
+// The optional response envelope.
+//
+// Tools that return structured JSON accept "envelope". When it is set, the
+// JSON block is wrapped in an object carrying metadata about the call:
+//
+//	{"requested_at": "...", "served_from_cache": false,
+//	 "backend_latency_ms": 12.5, "unit": "metric", "data": {...}}
+//
+// where data is the JSON the tool would otherwise have returned. The text
+// block is left alone, and without "envelope" the output stays as lean as
+// before. The metadata is collected per call by fetchBackend.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// envelopeOption declares the "envelope" argument of tools that return JSON.
+func envelopeOption() mcp.ToolOption {
+	return mcp.WithBoolean("envelope",
+		mcp.Description(`Wrap the JSON output in {"requested_at", "served_from_cache", "backend_latency_ms", "unit", "data"}, with the usual JSON as data; defaults to off`),
+	)
+}
+
+// envelope is the wrapper around a structured result.
+type envelope struct {
+	RequestedAt time.Time `json:"requested_at"`
+	// ServedFromCache is true when every backend response the call used came
+	// from the cache (including stale or last-known-good entries).
+	ServedFromCache bool `json:"served_from_cache"`
+	// BackendLatencyMS is the time spent waiting for the backend, in
+	// milliseconds; zero when nothing was fetched.
+	BackendLatencyMS float64         `json:"backend_latency_ms"`
+	Unit             string          `json:"unit,omitempty"`
+	Data             json.RawMessage `json:"data"`
+}
+
+// callStatsKey is the context key of a call's *callStats.
+type callStatsKey struct{}
+
+// callStats collects what fetchBackend did for one tool call. Tools such as
+// get_temperatures_batch fetch concurrently, so it is guarded by a mutex.
+type callStats struct {
+	mu        sync.Mutex
+	lookups   int
+	fromCache int
+	latency   time.Duration
+}
+
+// withCallStats returns a context collecting the stats of a tool call, and
+// the stats. A context already collecting them is returned as it is, so the
+// envelope and include_latency share the same stats.
+func withCallStats(ctx context.Context) (context.Context, *callStats) {
+	if stats, ok := ctx.Value(callStatsKey{}).(*callStats); ok {
+		return ctx, stats
+	}
+	stats := &callStats{}
+	return context.WithValue(ctx, callStatsKey{}, stats), stats
+}
+
+// recordBackend records a backend lookup of ctx's tool call: whether it was
+// answered from the cache, and how long the backend took otherwise.
+func recordBackend(ctx context.Context, fromCache bool, latency time.Duration) {
+	stats, ok := ctx.Value(callStatsKey{}).(*callStats)
+	if !ok {
+		return
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.lookups++
+	if fromCache {
+		stats.fromCache++
+	}
+	stats.latency += latency
+}
+
+// latencyMS returns the time spent waiting for the backend, in milliseconds
+// rounded to a tenth.
+func (s *callStats) latencyMS() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return math.Round(float64(s.latency.Microseconds())/100) / 10
+}
+
+// wrapEnvelope replaces the JSON block of result, the second content block,
+// with an envelope around it. Results without a JSON block are returned
+// unchanged.
+func wrapEnvelope(result *mcp.CallToolResult, requestedAt time.Time, stats *callStats, pretty bool) (*mcp.CallToolResult, error) {
+	if result == nil || result.IsError || len(result.Content) < 2 {
+		return result, nil
+	}
+	block, ok := result.Content[1].(mcp.TextContent)
+	if !ok || !json.Valid([]byte(block.Text)) {
+		return result, nil
+	}
+	var fields struct {
+		Unit string `json:"unit"`
+	}
+	_ = json.Unmarshal([]byte(block.Text), &fields)
+
+	latency := stats.latencyMS()
+	stats.mu.Lock()
+	env := envelope{
+		RequestedAt:      requestedAt.UTC(),
+		ServedFromCache:  stats.lookups > 0 && stats.fromCache == stats.lookups,
+		BackendLatencyMS: latency,
+		Unit:             fields.Unit,
+		Data:             json.RawMessage(block.Text),
+	}
+	stats.mu.Unlock()
+	b, err := marshalJSON(env, pretty)
+	if err != nil {
+		return nil, err
+	}
+	content := append([]mcp.Content{}, result.Content...)
+	content[1] = mcp.NewTextContent(string(b))
+	return &mcp.CallToolResult{Result: result.Result, Content: content}, nil
+}
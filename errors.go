@@ -0,0 +1,132 @@
+// errors.go
+// Error classes.
+//
+// Handler errors are classified with a small set of sentinel errors, so
+// callers such as the logging middleware can tell a bad argument from a
+// backend outage with errors.Is instead of matching message text. Errors keep
+// their own, specific message; the class only travels in the error chain.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error classes. Test for them with errors.Is.
+var (
+	// ErrLocationRequired means a tool was called without a location and
+	// no DEFAULT_LOCATION is configured.
+	ErrLocationRequired = errors.New("location must be a non-empty string")
+	// ErrNotPermitted means the location asked for is not in
+	// ALLOWED_LOCATIONS.
+	ErrNotPermitted = errors.New("location not permitted")
+	// ErrInvalidArgument means a tool argument was malformed or out of range.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrBackendUnavailable means the backend could not be reached or
+	// answered with a server error.
+	ErrBackendUnavailable = errors.New("temperature service unavailable")
+	// ErrRateLimited means the backend refused the request with 429 Too Many
+	// Requests.
+	ErrRateLimited = errors.New("temperature service rate limit exceeded")
+	// ErrNotFound means the backend answered 404 Not Found, usually for a
+	// location it does not know.
+	ErrNotFound = errors.New("not found by the temperature service")
+	// ErrInvalidResponse means the backend answered with a body that could
+	// not be understood.
+	ErrInvalidResponse = errors.New("invalid temperature service response")
+)
+
+// classifiedError is an error tagged with one of the error classes. Its
+// message is the wrapped error's alone.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string   { return e.err.Error() }
+func (e *classifiedError) Unwrap() []error { return []error{e.class, e.err} }
+
+// withClass tags err with class, keeping its message and chain.
+func withClass(class, err error) error {
+	return &classifiedError{class: class, err: err}
+}
+
+// invalidArgumentf returns an ErrInvalidArgument error with the given message.
+func invalidArgumentf(format string, args ...any) error {
+	return withClass(ErrInvalidArgument, fmt.Errorf(format, args...))
+}
+
+// checkLocationAllowed returns an ErrNotPermitted error for location unless
+// it, or any of the other names it goes by, is in ALLOWED_LOCATIONS.
+func checkLocationAllowed(location string, aka ...string) error {
+	if cfg.locationAllowed(append([]string{location}, aka...)...) {
+		return nil
+	}
+	return withClass(ErrNotPermitted, fmt.Errorf("location %q is not permitted on this server; allowed locations: %s",
+		location, strings.Join(cfg.AllowedLocations, ", ")))
+}
+
+// invalidResponsef returns an ErrInvalidResponse error with the given message.
+func invalidResponsef(format string, args ...any) error {
+	return withClass(ErrInvalidResponse, fmt.Errorf(format, args...))
+}
+
+// errorClasses pairs each error class with its name for logs, in order of
+// precedence: a timeout that wraps a backend error counts as a timeout.
+var errorClasses = []struct {
+	class error
+	name  string
+}{
+	{context.Canceled, "canceled"},
+	{context.DeadlineExceeded, "timeout"},
+	{ErrLocationRequired, "location_required"},
+	{ErrNotPermitted, "not_permitted"},
+	{ErrInvalidArgument, "invalid_argument"},
+	{ErrRateLimited, "rate_limited"},
+	{ErrNotFound, "not_found"},
+	{ErrBackendUnavailable, "backend_unavailable"},
+	{ErrInvalidResponse, "invalid_response"},
+}
+
+// classOf returns the class of err, or nil when it has none.
+func classOf(err error) error {
+	for _, c := range errorClasses {
+		if errors.Is(err, c.class) {
+			return c.class
+		}
+	}
+	return nil
+}
+
+// errorClass names the class of err for logs: one of location_required,
+// invalid_argument, backend_unavailable, rate_limited, not_found,
+// invalid_response, not_permitted, timeout, canceled or internal.
+func errorClass(err error) string {
+	for _, c := range errorClasses {
+		if errors.Is(err, c.class) {
+			return c.name
+		}
+	}
+	return "internal"
+}
+
+// sharedClass returns the class every one of errs has in common, or
+// ErrBackendUnavailable when they differ or have none. It is the class of a
+// call that failed because all of its parts did.
+func sharedClass(errs []error) error {
+	var class error
+	for i, err := range errs {
+		c := classOf(err)
+		if c == nil || (i > 0 && c != class) {
+			return ErrBackendUnavailable
+		}
+		class = c
+	}
+	if class == nil {
+		return ErrBackendUnavailable
+	}
+	return class
+}
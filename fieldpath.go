@@ -0,0 +1,86 @@
+// fieldpath.go
+// Configurable location of the temperature in backend responses.
+//
+// By default the temperature is the top-level "temperature" field. Backends
+// that nest it elsewhere, such as OpenWeatherMap-style {"main": {"temp": 21}},
+// can be used as they are by setting TEMPERATURE_FIELD to its dotted path
+// (main.temp). A numeric segment indexes an array, so data.0.temp reads the
+// first element of "data". The path is checked for well-formedness at
+// startup; a response in which it leads nowhere has no temperature.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultTemperatureField is the default TEMPERATURE_FIELD.
+const defaultTemperatureField = "temperature"
+
+// parseFieldPath splits a dotted field path into its segments, rejecting
+// empty segments and whitespace.
+func parseFieldPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("must not be empty")
+	}
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("segment %d of %q is empty", i+1, path)
+		}
+		if strings.ContainsAny(segment, " \t\r\n") {
+			return nil, fmt.Errorf("segment %q of %q contains whitespace", segment, path)
+		}
+	}
+	return segments, nil
+}
+
+// lookupField returns the value at path in a decoded JSON document, and
+// whether the path leads to a value at all.
+func lookupField(doc any, path []string) (any, bool) {
+	for _, segment := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// mappedTemperature reads the temperature at TEMPERATURE_FIELD from body. It
+// is nil when the path leads nowhere or to null, which reads as "no data";
+// any other non-number is an invalid response. With STRICT_RESPONSE, a path
+// that leads nowhere is an invalid response too.
+func mappedTemperature(body []byte) (*float64, error) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, invalidResponsef("failed to parse temperature response: %w", err)
+	}
+	v, ok := lookupField(doc, cfg.TemperaturePath)
+	if !ok && cfg.StrictResponse {
+		return nil, invalidResponsef("temperature response has no field %q", cfg.TemperatureField)
+	}
+	if !ok || v == nil {
+		return nil, nil
+	}
+	value, ok := v.(float64)
+	if !ok {
+		return nil, invalidResponsef("field %q of the temperature response must be a number, got %T", cfg.TemperatureField, v)
+	}
+	return &value, nil
+}
@@ -0,0 +1,379 @@
+// forecast.go
+// The "get_forecast" tool.
+//
+// get_forecast returns the temperature forecast for a location from the
+// backend's /forecast endpoint, either hour by hour (up to 48 hours ahead)
+// or day by day (up to FORECAST_MAX_DAYS, 7 by default), as chosen by the
+// "granularity" argument. With "as_series", the structured result carries
+// the values as compact [timestamp, value] series, ready to plot, instead of
+// the list of periods; the text summary is unchanged. "time_of_day" keeps
+// only the morning, noon, evening or night hours of an hourly forecast, or
+// only the highs (noon) or lows (night) of a daily one.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Forecast granularities.
+const (
+	granularityHourly = "hourly"
+	granularityDaily  = "daily"
+)
+
+// Forecast horizons, in days. Daily forecasts go as far as FORECAST_MAX_DAYS,
+// which defaults to defaultForecastMaxDays; hourly ones never go past
+// maxHourlyForecastDays.
+const (
+	maxHourlyForecastDays  = 2
+	defaultForecastMaxDays = 7
+)
+
+// timeOfDayHours are the local hours of each time_of_day window, as
+// [first, end); night wraps past midnight.
+var timeOfDayHours = map[string][2]int{
+	"morning": {6, 12},
+	"noon":    {12, 13},
+	"evening": {18, 22},
+	"night":   {22, 6},
+}
+
+// forecastHorizon returns the maximum and default "days" for granularity.
+func forecastHorizon(granularity string) (maxDays, defaultDays int) {
+	maxDays, defaultDays = cfg.ForecastMaxDays, 3
+	if granularity == granularityHourly {
+		maxDays, defaultDays = min(maxHourlyForecastDays, cfg.ForecastMaxDays), 1
+	}
+	return maxDays, min(defaultDays, maxDays)
+}
+
+// newForecastTool defines the "get_forecast" tool.
+func newForecastTool() mcp.Tool {
+	return mcp.NewTool("get_forecast",
+		readOnlyAnnotation("Temperature Forecast", true),
+		mcp.WithDescription("Get the hourly or daily temperature forecast for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the forecast for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithString("granularity",
+			mcp.Description(fmt.Sprintf("hourly for one temperature per hour (up to %d days ahead) or daily for the low and high of each day (up to %d days ahead); defaults to daily",
+				min(maxHourlyForecastDays, cfg.ForecastMaxDays), cfg.ForecastMaxDays)),
+			mcp.Enum(granularityHourly, granularityDaily),
+		),
+		mcp.WithNumber("days",
+			mcp.Description("Number of days to forecast, starting today; defaults to 1 for hourly and 3 for daily (or fewer, if the forecast horizon is shorter)"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system: metric (celsius), imperial (fahrenheit) or kelvin; defaults to metric"),
+		),
+		formatOptions("the value as reported"),
+		mcp.WithString("time_of_day",
+			mcp.Description("Keep only part of each day: for hourly forecasts morning (06-12), noon (the 12:00 hour), evening (18-22) or night (22-06) in local time; for daily ones noon keeps only the highs and night only the lows"),
+			mcp.Enum("morning", "noon", "evening", "night"),
+		),
+		mcp.WithBoolean("as_series",
+			mcp.Description("Return the structured data as [timestamp, value] series for plotting (temperature for hourly forecasts, min and max for daily ones) instead of a list of periods"),
+		),
+		langOption(),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// forecastResponse is the backend's /forecast response.
+type forecastResponse struct {
+	Location string           `json:"location"`
+	Timezone string           `json:"timezone,omitempty"`
+	Periods  []forecastPeriod `json:"periods"`
+}
+
+// forecastPeriod is one hour or one day of a forecast. Hourly periods carry
+// a temperature; daily ones carry the day's low and high.
+type forecastPeriod struct {
+	Time        time.Time `json:"time"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Min         *float64  `json:"min,omitempty"`
+	Max         *float64  `json:"max,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+}
+
+// forecastResult is the structured result of get_forecast.
+type forecastResult struct {
+	Location    string           `json:"location"`
+	Granularity string           `json:"granularity"`
+	Days        int              `json:"days"`
+	Unit        string           `json:"unit"`
+	TimeOfDay   string           `json:"time_of_day,omitempty"`
+	Periods     []forecastPeriod `json:"periods,omitempty"`
+	// Series holds the values as named series instead of Periods, when
+	// asked for with as_series.
+	Series map[string][]seriesPoint `json:"series,omitempty"`
+	Stale  bool                     `json:"stale,omitempty"`
+}
+
+// seriesPoint is one point of a forecast series. It is encoded as a compact
+// [timestamp, value] pair, with a null value where the backend has none.
+type seriesPoint struct {
+	Time  time.Time
+	Value *float64
+}
+
+func (p seriesPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{p.Time, p.Value})
+}
+
+// forecastSeries returns the series of periods: "temperature" for hourly
+// forecasts, "min" and "max" for daily ones.
+func forecastSeries(periods []forecastPeriod, granularity string) map[string][]seriesPoint {
+	series := make(map[string][]seriesPoint)
+	for _, p := range periods {
+		if granularity == granularityHourly {
+			series["temperature"] = append(series["temperature"], seriesPoint{p.Time, p.Temperature})
+			continue
+		}
+		low, high := p.Min, p.Max
+		// A day reported as a single temperature is both its low and high.
+		if low == nil && high == nil {
+			low, high = p.Temperature, p.Temperature
+		}
+		series["min"] = append(series["min"], seriesPoint{p.Time, low})
+		series["max"] = append(series["max"], seriesPoint{p.Time, high})
+	}
+	return series
+}
+
+// forecastArgs reads and validates the "granularity" and "days" arguments.
+func forecastArgs(request mcp.CallToolRequest) (granularity string, days int, err error) {
+	granularity = strings.ToLower(strings.TrimSpace(mcp.ParseString(request, "granularity", granularityDaily)))
+	if granularity != granularityDaily && granularity != granularityHourly {
+		return "", 0, invalidArgumentf("granularity must be hourly or daily, got %q", granularity)
+	}
+	maxDays, defaultDays := forecastHorizon(granularity)
+	days = mcp.ParseInt(request, "days", defaultDays)
+	if days < 1 || days > maxDays {
+		return "", 0, invalidArgumentf("days must be between 1 and %d for %s forecasts, got %d", maxDays, granularity, days)
+	}
+	return granularity, days, nil
+}
+
+// timeOfDayArg reads and validates the "time_of_day" argument; a daily
+// forecast can only be filtered to its highs (noon) or lows (night).
+func timeOfDayArg(request mcp.CallToolRequest, granularity string) (string, error) {
+	timeOfDay := strings.ToLower(strings.TrimSpace(mcp.ParseString(request, "time_of_day", "")))
+	if timeOfDay == "" {
+		return "", nil
+	}
+	if _, ok := timeOfDayHours[timeOfDay]; !ok {
+		return "", invalidArgumentf("time_of_day must be morning, noon, evening or night, got %q", timeOfDay)
+	}
+	if granularity == granularityDaily && timeOfDay != "noon" && timeOfDay != "night" {
+		return "", invalidArgumentf("time_of_day %s needs an hourly forecast; daily forecasts can only be filtered to noon (highs) or night (lows)", timeOfDay)
+	}
+	return timeOfDay, nil
+}
+
+// filterTimeOfDay keeps the periods of an hourly forecast whose local hour in
+// loc falls in the timeOfDay window. For a daily forecast it keeps every day
+// but only its high (noon) or low (night).
+func filterTimeOfDay(periods []forecastPeriod, granularity, timeOfDay string, loc *time.Location) []forecastPeriod {
+	if granularity == granularityDaily {
+		for i := range periods {
+			// A day reported as a single temperature is kept as it is.
+			if timeOfDay == "noon" && periods[i].Max != nil {
+				periods[i].Min = nil
+			} else if timeOfDay == "night" && periods[i].Min != nil {
+				periods[i].Max = nil
+			}
+		}
+		return periods
+	}
+	window := timeOfDayHours[timeOfDay]
+	var kept []forecastPeriod
+	for _, p := range periods {
+		hour := p.Time.In(loc).Hour()
+		in := hour >= window[0] && hour < window[1]
+		if window[0] > window[1] {
+			in = hour >= window[0] || hour < window[1]
+		}
+		if in {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// forecastHandler handles incoming requests to the "get_forecast" tool.
+func forecastHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[forecastHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	granularity, days, err := forecastArgs(request)
+	if err != nil {
+		return nil, err
+	}
+	timeOfDay, err := timeOfDayArg(request, granularity)
+	if err != nil {
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := normalizeUnit(mcp.ParseString(request, "unit", ""))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnitSupported(unit); err != nil {
+		return nil, err
+	}
+	lang, err := langArg(request)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	query.setParams(params)
+	params.Set("units", backendUnit(unit))
+	params.Set("granularity", granularity)
+	params.Set("days", strconv.Itoa(days))
+	setLangParam(params, lang)
+	backend, err := fetchBackend(ctx, "/forecast", params)
+	if err != nil {
+		return nil, err
+	}
+	var forecast forecastResponse
+	if err := json.Unmarshal(backend.Body, &forecast); err != nil {
+		return nil, invalidResponsef("failed to parse forecast response: %w", err)
+	}
+	if len(forecast.Periods) == 0 {
+		return nil, invalidResponsef("forecast response has no periods")
+	}
+
+	// Never show more than was asked for, whatever the backend sent.
+	limit := days
+	if granularity == granularityHourly {
+		limit = days * 24
+	}
+	if len(forecast.Periods) > limit {
+		forecast.Periods = forecast.Periods[:limit]
+	}
+
+	// Show times in the location's own time zone when the backend names one.
+	loc := forecast.Periods[0].Time.Location()
+	if forecast.Timezone != "" {
+		if tz, err := time.LoadLocation(forecast.Timezone); err == nil {
+			loc = tz
+		} else {
+			logf(ctx, "[forecastHandler] WARNING: unknown time zone %q: %v", forecast.Timezone, err)
+		}
+	}
+
+	var b strings.Builder
+	span, filter := len(forecast.Periods), ""
+	if timeOfDay != "" {
+		forecast.Periods = filterTimeOfDay(forecast.Periods, granularity, timeOfDay, loc)
+		filter = ", " + timeOfDay + " only"
+	}
+	if granularity == granularityHourly {
+		fmt.Fprintf(&b, "Hourly forecast for %s (next %d hours%s):", query.Label, span, filter)
+	} else {
+		fmt.Fprintf(&b, "Daily forecast for %s (next %d days%s):", query.Label, span, filter)
+	}
+	if len(forecast.Periods) == 0 {
+		fmt.Fprintf(&b, "\n- no forecast hours fall in the %s window", timeOfDay)
+	}
+	for i := range forecast.Periods {
+		p := &forecast.Periods[i]
+		p.Temperature = convertForecastValue(p.Temperature, unit, format)
+		p.Min = convertForecastValue(p.Min, unit, format)
+		p.Max = convertForecastValue(p.Max, unit, format)
+		fmt.Fprintf(&b, "\n- %s", forecastLine(*p, granularity, loc, unit, format))
+	}
+	b.WriteString(backend.staleNote())
+
+	result := forecastResult{
+		Location:    location,
+		Granularity: granularity,
+		Days:        days,
+		Unit:        unit,
+		TimeOfDay:   timeOfDay,
+		Periods:     forecast.Periods,
+		Stale:       backend.Stale,
+	}
+	if mcp.ParseBoolean(request, "as_series", false) {
+		result.Periods, result.Series = nil, forecastSeries(forecast.Periods, granularity)
+		// A daily forecast filtered to its highs or lows has only one series.
+		if granularity == granularityDaily {
+			switch timeOfDay {
+			case "noon":
+				delete(result.Series, "min")
+			case "night":
+				delete(result.Series, "max")
+			}
+		}
+	}
+	return newStructuredResult(b.String(), result, prettyArg(request))
+}
+
+// convertForecastValue converts a forecast value fetched in metric to Kelvin
+// when asked for, and rounds it.
+func convertForecastValue(v *float64, unit string, format numberFormat) *float64 {
+	if v == nil {
+		return nil
+	}
+	value := *v
+	if unit == "kelvin" {
+		value = celsiusToKelvin(value)
+	}
+	value = format.round(value)
+	return &value
+}
+
+// forecastLine formats one forecast period.
+func forecastLine(p forecastPeriod, granularity string, loc *time.Location, unit string, format numberFormat) string {
+	var line string
+	if granularity == granularityHourly {
+		line = p.Time.In(loc).Format("Mon 15:04 MST") + ": "
+		if p.Temperature != nil {
+			line += formatTemperature(*p.Temperature, unit, format)
+		} else {
+			line += "unavailable"
+		}
+	} else {
+		line = p.Time.In(loc).Format("Mon "+dateLayout) + ": "
+		switch {
+		case p.Min != nil && p.Max != nil:
+			line += fmt.Sprintf("%s to %s", formatTemperature(*p.Min, unit, format), formatTemperature(*p.Max, unit, format))
+		case p.Min != nil:
+			line += "low " + formatTemperature(*p.Min, unit, format)
+		case p.Max != nil:
+			line += "high " + formatTemperature(*p.Max, unit, format)
+		case p.Temperature != nil:
+			line += formatTemperature(*p.Temperature, unit, format)
+		default:
+			line += "unavailable"
+		}
+	}
+	if p.Summary != "" {
+		line += ", " + p.Summary
+	}
+	return line
+}
@@ -0,0 +1,109 @@
+// format.go
+// Number formatting shared by the tools.
+//
+// Every numeric value a tool reports goes through numberFormat, so the
+// precision and rounding options behave the same way everywhere. Text output
+// uses DECIMAL_SEPARATOR (e.g. "21,5°C"); structured JSON always carries
+// plain numbers.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Rounding modes. "round" rounds half to even, so 0.5 and 1.5 both become
+// whole numbers without a systematic upward bias.
+const (
+	roundHalfEven = "round"
+	roundFloor    = "floor"
+	roundCeil     = "ceil"
+)
+
+// maxPrecision is the largest number of decimals a client may request.
+const maxPrecision = 6
+
+// numberFormat controls how numeric values are rounded and rendered.
+type numberFormat struct {
+	// Precision is the number of decimals, or -1 to keep the value as reported.
+	Precision int
+	// Rounding is one of roundHalfEven, roundFloor or roundCeil.
+	Rounding string
+}
+
+// formatOptions declares the "precision" and "rounding" arguments read by
+// numberFormatArg. precisionDefault describes the precision used when none is
+// given, e.g. "the value as reported".
+func formatOptions(precisionDefault string) mcp.ToolOption {
+	precision := mcp.WithNumber("precision",
+		mcp.Description(fmt.Sprintf("Number of decimals to show (0-%d); defaults to %s", maxPrecision, precisionDefault)),
+	)
+	rounding := mcp.WithString("rounding",
+		mcp.Description("How to round to the precision: round (half to even, the default), floor or ceil"),
+		mcp.Enum(roundHalfEven, roundFloor, roundCeil),
+	)
+	return func(t *mcp.Tool) {
+		precision(t)
+		rounding(t)
+	}
+}
+
+// numberFormatArg reads the "precision" and "rounding" arguments. A rounding
+// mode without a precision rounds to whole numbers; a precision without a
+// rounding mode rounds half to even.
+func numberFormatArg(request mcp.CallToolRequest) (numberFormat, error) {
+	f := numberFormat{Precision: -1, Rounding: roundHalfEven}
+	rounding := strings.ToLower(strings.TrimSpace(mcp.ParseString(request, "rounding", "")))
+	if rounding != "" {
+		switch rounding {
+		case roundHalfEven, roundFloor, roundCeil:
+			f.Rounding = rounding
+			f.Precision = 0
+		default:
+			return f, invalidArgumentf("rounding must be one of round, floor or ceil, got %q", rounding)
+		}
+	}
+	if _, ok := request.Params.Arguments["precision"]; ok {
+		p := mcp.ParseInt(request, "precision", -1)
+		if p < 0 || p > maxPrecision {
+			return f, invalidArgumentf("precision must be between 0 and %d", maxPrecision)
+		}
+		f.Precision = p
+	}
+	return f, nil
+}
+
+// round returns v rounded according to f.
+func (f numberFormat) round(v float64) float64 {
+	if f.Precision < 0 {
+		return v
+	}
+	scale := math.Pow10(f.Precision)
+	// v*scale carries binary float error (2.3*100 is 229.99999999999997),
+	// which would send floor and ceil to the wrong side of a value that is
+	// whole at this precision; snap it to nine decimals first.
+	x := math.Round(v*scale*1e9) / 1e9
+	switch f.Rounding {
+	case roundFloor:
+		return math.Floor(x) / scale
+	case roundCeil:
+		return math.Ceil(x) / scale
+	default:
+		return math.RoundToEven(x) / scale
+	}
+}
+
+// format rounds v according to f and renders it as text with the
+// configured decimal separator.
+func (f numberFormat) format(v float64) string {
+	s := strconv.FormatFloat(f.round(v), 'f', f.Precision, 64)
+	if cfg.DecimalSeparator != "." {
+		s = strings.Replace(s, ".", cfg.DecimalSeparator, 1)
+	}
+	return s
+}
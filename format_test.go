@@ -0,0 +1,43 @@
+// format_test.go
+// Tests of the shared number formatting.
+
+package main
+
+import "testing"
+
+func TestNumberFormatRound(t *testing.T) {
+	tests := []struct {
+		v         float64
+		precision int
+		rounding  string
+		want      float64
+	}{
+		{2.5, 0, roundHalfEven, 2},
+		{3.5, 0, roundHalfEven, 4},
+		{-2.5, 0, roundHalfEven, -2},
+		{2.7, 0, roundFloor, 2},
+		{-2.3, 0, roundFloor, -3},
+		{2.3, 0, roundCeil, 3},
+		{2.0, 0, roundCeil, 2},
+		{2.25, 1, roundHalfEven, 2.2},
+		{2.35, 1, roundHalfEven, 2.4},
+		{8.2, 1, roundFloor, 8.2},
+		{8.29, 1, roundFloor, 8.2},
+		{1.1, 1, roundCeil, 1.1},
+		{1.11, 1, roundCeil, 1.2},
+		{18.456, 2, roundHalfEven, 18.46},
+		{2.3, 2, roundFloor, 2.3},
+		{8.2, 2, roundFloor, 8.2},
+		{4.35, 2, roundFloor, 4.35},
+		{4.359, 2, roundFloor, 4.35},
+		{1.1, 2, roundCeil, 1.1},
+		{1.101, 2, roundCeil, 1.11},
+		{-4.35, 2, roundCeil, -4.35},
+	}
+	for _, tt := range tests {
+		f := numberFormat{Precision: tt.precision, Rounding: tt.rounding}
+		if got := f.round(tt.v); got != tt.want {
+			t.Errorf("round(%v) with %s at precision %d = %v, want %v", tt.v, tt.rounding, tt.precision, got, tt.want)
+		}
+	}
+}
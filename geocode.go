@@ -0,0 +1,92 @@
+// geocode.go
+// Resolution of place names to coordinates.
+//
+// With GEOCODE_PATH set, place names are turned into coordinates by the
+// backend's geocoding endpoint (e.g. /geocode?q=Paris → {"lat": 48.85,
+// "lon": 2.35}) and every tool then queries the backend by coordinates.
+// Those mappings almost never change, so they are cached on their own, for
+// GEOCODE_CACHE_TTL (a day by default), independently of the much shorter
+// CACHE_TTL of temperature values: a repeated location goes straight to the
+// temperature query.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// geocodeCache holds geocoding responses, keyed by normalized place name.
+var geocodeCache responseCache = noCache{}
+
+// newGeocodeCache builds the geocoding cache for c. It lives in the same
+// place (memory or Redis) as the response cache, but never serves stale.
+func newGeocodeCache(c *config) (responseCache, error) {
+	if c.GeocodePath == "" || c.GeocodeCacheTTL <= 0 {
+		return noCache{}, nil
+	}
+	switch c.CacheBackend {
+	case "redis":
+		return newRedisCache(c.RedisURL, c.GeocodeCacheTTL, 0)
+	default:
+		return newMemoryCache(c.GeocodeCacheTTL, 0, c.CacheMaxEntries), nil
+	}
+}
+
+// geocodeResponse is the backend's geocoding response.
+type geocodeResponse struct {
+	Lat *float64 `json:"lat"`
+	Lon *float64 `json:"lon"`
+}
+
+// geocode resolves a place name to coordinates, from the geocoding cache
+// when possible. Only successful lookups are cached, and only when the
+// backend does not mark them no-store.
+func geocode(ctx context.Context, name string) (lat, lon float64, err error) {
+	cacheKey := "geocode:" + strings.ToLower(strings.TrimSpace(name))
+	if entry, ok := geocodeCache.get(ctx, cacheKey); ok {
+		if lat, lon, err = parseGeocode(entry.body, name); err == nil {
+			logf(ctx, "[geocode] Cache hit for %q", name)
+			return lat, lon, nil
+		}
+	}
+
+	params := url.Values{}
+	params.Set("q", name)
+	cfg.setAuthParams(params)
+	reqUrl := backendURL(cfg.Endpoint, cfg.apiPath(cfg.GeocodePath), params)
+	// The URL carries the API key, so it only goes to the file log.
+	log.Printf("[geocode] Requesting URL: %s", logCoordinates(reqUrl))
+
+	body, keep, err := fetchShared(ctx, noCache{}, cacheKey, reqUrl)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to geocode %q: %w", name, err)
+	}
+	if lat, lon, err = parseGeocode(body, name); err != nil {
+		return 0, 0, err
+	}
+	if keep {
+		geocodeCache.set(ctx, cacheKey, body, 0)
+	}
+	logf(ctx, "[geocode] Resolved %q to %g,%g", name, lat, lon)
+	return lat, lon, nil
+}
+
+// parseGeocode reads the coordinates from a geocoding response for name.
+func parseGeocode(body []byte, name string) (lat, lon float64, err error) {
+	var resp geocodeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, 0, invalidResponsef("failed to parse geocoding response for %q: %w", name, err)
+	}
+	if resp.Lat == nil || resp.Lon == nil {
+		return 0, 0, invalidResponsef("could not geocode %q: no coordinates in the response", name)
+	}
+	if *resp.Lat < -90 || *resp.Lat > 90 || *resp.Lon < -180 || *resp.Lon > 180 {
+		return 0, 0, invalidResponsef("could not geocode %q: coordinates %g,%g are out of range", name, *resp.Lat, *resp.Lon)
+	}
+	return *resp.Lat, *resp.Lon, nil
+}
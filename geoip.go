@@ -0,0 +1,106 @@
+// geoip.go
+// IP geolocation for get_temperature's auto_locate mode.
+//
+// With auto_locate (or AUTO_LOCATE=true) and no location given, the client's
+// location is looked up from its IP address through the backend's /geoip
+// endpoint and its temperature is returned: "what's the weather here". Over
+// SSE the client's address is passed as the "ip" parameter; over stdio the
+// client runs on the server's machine, so the backend geolocates the address
+// the request comes from. If geolocation fails, DEFAULT_LOCATION is used
+// instead when one is configured, and the call fails with a clear error
+// otherwise.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// clientIPKey is the context key holding the IP address of an SSE client.
+type clientIPKey struct{}
+
+// withClientIP records the address of the client of r in ctx. It is the SSE
+// server's context function, so it runs for every message a client posts.
+// Loopback and private addresses are left out: the backend cannot
+// geolocate them, but can geolocate the public address they reach it from.
+func withClientIP(ctx context.Context, r *http.Request) context.Context {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ctx
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() {
+		return ctx
+	}
+	return context.WithValue(ctx, clientIPKey{}, ip.String())
+}
+
+// geoIPResponse is the backend's /geoip response.
+type geoIPResponse struct {
+	Lat     *float64 `json:"lat"`
+	Lon     *float64 `json:"lon"`
+	City    string   `json:"city,omitempty"`
+	Country string   `json:"country,omitempty"`
+}
+
+// geolocate looks up the location of the client of ctx. name is the place
+// found ("Chapel Hill, US"), or its coordinates when the backend names none.
+func geolocate(ctx context.Context) (name string, query locationQuery, err error) {
+	params := url.Values{}
+	if ip, ok := ctx.Value(clientIPKey{}).(string); ok {
+		params.Set("ip", ip)
+	}
+	backend, err := fetchBackend(ctx, "/geoip", params)
+	if err != nil {
+		return "", locationQuery{}, err
+	}
+	var resp geoIPResponse
+	if err := json.Unmarshal(backend.Body, &resp); err != nil {
+		return "", locationQuery{}, invalidResponsef("failed to parse geolocation response: %w", err)
+	}
+	if resp.Lat == nil || resp.Lon == nil {
+		return "", locationQuery{}, invalidResponsef("geolocation response has no coordinates")
+	}
+	if *resp.Lat < -90 || *resp.Lat > 90 || *resp.Lon < -180 || *resp.Lon > 180 {
+		return "", locationQuery{}, invalidResponsef("geolocation returned out-of-range coordinates %g,%g", *resp.Lat, *resp.Lon)
+	}
+
+	var parts []string
+	for _, part := range []string{resp.City, resp.Country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	name = strings.Join(parts, ", ")
+	if name == "" {
+		name = strconv.FormatFloat(*resp.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(*resp.Lon, 'f', -1, 64)
+	}
+	if err := checkLocationAllowed(name, resp.City); err != nil {
+		return "", locationQuery{}, err
+	}
+	query = locationQuery{Lat: *resp.Lat, Lon: *resp.Lon, Label: fmt.Sprintf("your location (%s)", name), Geocoded: name}
+	logf(ctx, "[geolocate] Located client at %s (%g,%g)", name, *resp.Lat, *resp.Lon)
+	return name, query, nil
+}
+
+// temperatureHere returns the temperature at the client's geolocated
+// location, falling back to DEFAULT_LOCATION when geolocation fails.
+func temperatureHere(ctx context.Context, opts temperatureOptions) (temperatureResult, error) {
+	name, query, err := geolocate(ctx)
+	if err == nil {
+		return temperatureForQuery(ctx, name, query, opts)
+	}
+	if cfg.DefaultLocation == "" {
+		return temperatureResult{}, withClass(ErrLocationRequired,
+			fmt.Errorf("could not determine your location from your IP address (%v); pass a location instead", err))
+	}
+	logf(ctx, "[temperatureHere] WARNING: geolocation failed, using DEFAULT_LOCATION %q: %v", cfg.DefaultLocation, err)
+	return temperatureFor(ctx, cfg.DefaultLocation, opts)
+}
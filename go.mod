@@ -2,9 +2,15 @@ module github.com/omaciel/go-mcp-temperature-server
 
 go 1.24.1
 
-require github.com/mark3labs/mcp-go v0.26.0
+require (
+	github.com/mark3labs/mcp-go v0.26.0
+	github.com/redis/go-redis/v9 v9.7.3
+	golang.org/x/sync v0.16.0
+)
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/spf13/cast v1.7.1 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
@@ -0,0 +1,123 @@
+// health.go
+// The "health_check" tool.
+//
+// health_check sends a single request to the backend's health endpoint
+// (HEALTH_PATH, /health by default) and reports the status code and latency.
+// It bypasses the cache and retries so it always reflects the backend's
+// current state, and a failing backend is reported, not returned as an error.
+//
+// The same check backs STARTUP_WAIT, which holds startup until the backend is
+// healthy, for orchestrated setups where it may start after this server.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newHealthCheckTool defines the "health_check" tool. It only takes "pretty".
+func newHealthCheckTool() mcp.Tool {
+	return mcp.NewTool("health_check",
+		readOnlyAnnotation("Backend Health Check", true),
+		mcp.WithDescription("Check whether the temperature backend is up, reporting its health endpoint's status code and latency"),
+		prettyOption(),
+	)
+}
+
+// healthResult is the structured result of health_check.
+type healthResult struct {
+	Path       string `json:"path"`
+	Healthy    bool   `json:"healthy"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// healthCheckHandler handles incoming requests to the "health_check" tool.
+func healthCheckHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	result := checkHealth(ctx)
+	var text string
+	switch {
+	case result.Error != "":
+		text = fmt.Sprintf("Backend %s is unreachable after %dms: %s", cfg.backendHost(), result.LatencyMS, result.Error)
+	case result.Healthy:
+		text = fmt.Sprintf("Backend %s is healthy: %s returned %d in %dms", cfg.backendHost(), result.Path, result.StatusCode, result.LatencyMS)
+	default:
+		text = fmt.Sprintf("Backend %s is unhealthy: %s returned %d in %dms", cfg.backendHost(), result.Path, result.StatusCode, result.LatencyMS)
+	}
+	return newStructuredResult(text, result, prettyArg(request))
+}
+
+// checkHealth requests the health endpoint once. Any 2xx status is healthy.
+func checkHealth(ctx context.Context) healthResult {
+	result := healthResult{Path: cfg.HealthPath}
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout(ctx))
+	defer cancel()
+
+	params := url.Values{}
+	cfg.setAuthParams(params)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, backendURL(cfg.Endpoint, cfg.HealthPath, params), nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	cfg.setAuthHeaders(req)
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		// Report the cause without the request URL, which may carry the API key.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		logf(ctx, "[checkHealth] ERROR: %v", err)
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	result.StatusCode = resp.StatusCode
+	result.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+	logf(ctx, "[checkHealth] %s returned %s in %dms", cfg.HealthPath, resp.Status, result.LatencyMS)
+	return result
+}
+
+// waitForBackend polls the health endpoint every interval until it reports
+// healthy or timeout passes, logging each failed attempt. It returns an
+// error if the backend never became healthy.
+func waitForBackend(timeout, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	log.Printf("[waitForBackend] Waiting up to %s for backend %s to become healthy", timeout, cfg.backendHost())
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for attempt := 1; ; attempt++ {
+		result := checkHealth(ctx)
+		if result.Healthy {
+			log.Printf("[waitForBackend] Backend is healthy after %d attempt(s) in %s", attempt, time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+		reason := result.Error
+		if reason == "" {
+			reason = fmt.Sprintf("%s returned %d", result.Path, result.StatusCode)
+		}
+		log.Printf("[waitForBackend] Attempt %d: backend not ready: %s", attempt, reason)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("backend %s did not become healthy within %s", cfg.backendHost(), timeout)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// info.go
+// The "server_info" tool.
+//
+// server_info reports the effective configuration of the running server so
+// agents and operators can confirm what they are talking to without reading
+// the log file. It never includes the API key.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// startTime records when the process started, for reporting uptime.
+var startTime = time.Now()
+
+// newServerInfoTool defines the "server_info" tool. It takes no parameters.
+func newServerInfoTool() mcp.Tool {
+	return mcp.NewTool("server_info",
+		readOnlyAnnotation("Server Info", false),
+		mcp.WithDescription("Show the server's name, version, transport, backend host, cache status and uptime"),
+	)
+}
+
+// serverInfoHandler handles incoming requests to the "server_info" tool.
+func serverInfoHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name: %s\n", serverName)
+	fmt.Fprintf(&b, "Version: %s\n", serverVersion)
+	fmt.Fprintf(&b, "Transport: %s\n", cfg.Transport)
+	fmt.Fprintf(&b, "Backend host: %s\n", cfg.backendHost())
+	fmt.Fprintf(&b, "Cache: %s\n", cache.status())
+	fmt.Fprintf(&b, "Last known good: %s\n", lastGood.status())
+	fmt.Fprintf(&b, "Minimum refresh interval: %s\n", recentFetches.status())
+	fmt.Fprintf(&b, "Unique locations queried: %s\n", uniqueLocations.status())
+	if cfg.GeocodePath != "" {
+		fmt.Fprintf(&b, "Geocoding: %s, cache %s\n", cfg.GeocodePath, geocodeCache.status())
+	}
+	if cfg.MoonPath != "" {
+		fmt.Fprintf(&b, "Moon phase: %s\n", cfg.MoonPath)
+	}
+	fmt.Fprintf(&b, "Uptime: %s", time.Since(startTime).Round(time.Second))
+	return mcp.NewToolResultText(b.String()), nil
+}
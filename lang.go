@@ -0,0 +1,84 @@
+// lang.go
+// The language of backend descriptions.
+//
+// Backends that localize their descriptions (forecast summaries, alert
+// titles, ...) take a lang= query parameter. get_temperature, get_forecast
+// and get_alerts accept a "lang" argument, defaulting to DEFAULT_LANG, and
+// forward it to the backend; without either, no lang= is sent and the
+// backend answers in its own default language. Codes are checked against
+// SUPPORTED_LANGS, matched case-insensitively and with "-" and "_" taken as
+// the same, and sent in the spelling that list uses.
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultSupportedLangs is the default SUPPORTED_LANGS: the language codes
+// commonly understood by weather backends.
+const defaultSupportedLangs = "ar;bg;ca;cs;da;de;el;en;es;fa;fi;fr;he;hi;hr;hu;id;it;ja;ko;nl;no;pl;pt;pt_br;ro;ru;sk;sl;sr;sv;th;tr;uk;vi;zh_cn;zh_tw"
+
+// langOption declares the "lang" argument of tools whose backend responses
+// carry descriptions.
+func langOption() mcp.ToolOption {
+	return mcp.WithString("lang",
+		mcp.Description("Language code for the backend's descriptions, such as es or pt_br; defaults to the server's DEFAULT_LANG, if set, and the backend's own language otherwise"),
+	)
+}
+
+// parseSupportedLangs parses a semicolon-separated list of language codes.
+func parseSupportedLangs(raw string) ([]string, error) {
+	var langs []string
+	for _, lang := range strings.Split(raw, ";") {
+		if lang = strings.TrimSpace(lang); lang == "" {
+			continue
+		}
+		if strings.ContainsFunc(lang, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+		}) {
+			return nil, fmt.Errorf("%q is not a language code", lang)
+		}
+		langs = append(langs, lang)
+	}
+	if len(langs) == 0 {
+		return nil, fmt.Errorf("at least one language code is required")
+	}
+	return langs, nil
+}
+
+// matchLang returns the entry of supported that lang stands for.
+func matchLang(lang string, supported []string) (string, bool) {
+	key := strings.ReplaceAll(strings.TrimSpace(lang), "-", "_")
+	for _, s := range supported {
+		if strings.EqualFold(strings.ReplaceAll(s, "-", "_"), key) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// langArg reads the "lang" argument, falling back to DEFAULT_LANG. An empty
+// result means no language was asked for.
+func langArg(request mcp.CallToolRequest) (string, error) {
+	lang := mcp.ParseString(request, "lang", "")
+	if strings.TrimSpace(lang) == "" {
+		return cfg.DefaultLang, nil
+	}
+	match, ok := matchLang(lang, cfg.SupportedLangs)
+	if !ok {
+		return "", invalidArgumentf("unsupported lang %q: must be one of %s", lang, strings.Join(cfg.SupportedLangs, ", "))
+	}
+	return match, nil
+}
+
+// setLangParam adds the lang= backend parameter, when a language was asked for.
+func setLangParam(params url.Values, lang string) {
+	if lang != "" {
+		params.Set("lang", lang)
+	}
+}
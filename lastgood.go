@@ -0,0 +1,86 @@
+// lastgood.go
+// The last-known-good store.
+//
+// With LAST_KNOWN_GOOD set, the last successful backend response for every
+// query is kept in a small in-memory store, separate from the TTL cache and
+// never expiring. When a fresh fetch fails and no stale cache entry can be
+// served, that response is returned instead of the error, marked with when it
+// was fetched. It trades freshness for availability, for agents that would
+// rather have old data than none.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxLastGoodEntries bounds the last-known-good store; when it is full, the
+// oldest response makes room for a new query.
+const maxLastGoodEntries = 256
+
+// lastGood is the last-known-good store, or nil when LAST_KNOWN_GOOD is off.
+var lastGood *lastGoodStore
+
+// lastGoodEntry is a successful backend response and when it was fetched.
+type lastGoodEntry struct {
+	body      []byte
+	fetchedAt time.Time
+}
+
+// lastGoodStore holds the last successful response for each cache key.
+// A nil store holds nothing.
+type lastGoodStore struct {
+	mu      sync.Mutex
+	entries map[string]lastGoodEntry
+}
+
+// newLastGoodStore returns the last-known-good store for c, or nil when it
+// is disabled.
+func newLastGoodStore(c *config) *lastGoodStore {
+	if !c.LastKnownGood {
+		return nil
+	}
+	return &lastGoodStore{entries: make(map[string]lastGoodEntry)}
+}
+
+// get returns the last successful response for key, if any.
+func (s *lastGoodStore) get(key string) (lastGoodEntry, bool) {
+	if s == nil {
+		return lastGoodEntry{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	return entry, ok
+}
+
+// set records body, fetched at fetchedAt, as the last successful response for key.
+func (s *lastGoodStore) set(key string, body []byte, fetchedAt time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= maxLastGoodEntries {
+		oldest := ""
+		for k, e := range s.entries {
+			if oldest == "" || e.fetchedAt.Before(s.entries[oldest].fetchedAt) {
+				oldest = k
+			}
+		}
+		delete(s.entries, oldest)
+	}
+	s.entries[key] = lastGoodEntry{body: body, fetchedAt: fetchedAt}
+}
+
+// status describes the store for server_info.
+func (s *lastGoodStore) status() string {
+	if s == nil {
+		return "disabled"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("enabled (%d of %d entries)", len(s.entries), maxLastGoodEntries)
+}
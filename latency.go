@@ -0,0 +1,83 @@
+// latency.go
+// The optional backend latency report.
+//
+// Tools that fetch from the backend accept "include_latency", for clients
+// profiling their own calls. When it is set, the time spent waiting for the
+// backend is appended to the text, e.g. " [backend: 12.5 ms]", and added as
+// "backend_latency_ms" to the JSON block when it is an object. It is the
+// same figure the envelope reports, timed around each backend call by
+// fetchBackend; answers from the cache take no time, and a call answered
+// entirely from it says so in the text.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// latencyOption declares the "include_latency" argument of tools that fetch
+// from the backend.
+func latencyOption() mcp.ToolOption {
+	return mcp.WithBoolean("include_latency",
+		mcp.Description("Also report how long the backend took to answer, in the text and as backend_latency_ms in the JSON output; defaults to off"),
+	)
+}
+
+// addLatency appends the backend latency collected in stats to the text
+// block of result and, when it is a JSON object, to its JSON block.
+func addLatency(result *mcp.CallToolResult, stats *callStats, pretty bool) *mcp.CallToolResult {
+	if result == nil || result.IsError || len(result.Content) == 0 {
+		return result
+	}
+	latency := stats.latencyMS()
+	stats.mu.Lock()
+	note := fmt.Sprintf(" [backend: %s ms]", strconv.FormatFloat(latency, 'f', -1, 64))
+	if stats.lookups > 0 && stats.fromCache == stats.lookups {
+		note = " [backend: served from the cache]"
+	}
+	stats.mu.Unlock()
+	content := append([]mcp.Content{}, result.Content...)
+	if text, ok := content[0].(mcp.TextContent); ok {
+		content[0] = mcp.NewTextContent(text.Text + note)
+	}
+	if len(content) > 1 {
+		if block, ok := content[1].(mcp.TextContent); ok {
+			if b, ok := withLatencyField([]byte(block.Text), latency, pretty); ok {
+				content[1] = mcp.NewTextContent(string(b))
+			}
+		}
+	}
+	return &mcp.CallToolResult{Result: result.Result, Content: content}
+}
+
+// withLatencyField returns the JSON object doc with a trailing
+// "backend_latency_ms" field, keeping the order of the others. ok is false
+// when doc is not a JSON object.
+func withLatencyField(doc []byte, latency float64, pretty bool) ([]byte, bool) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, doc); err != nil {
+		return nil, false
+	}
+	b := compact.Bytes()
+	if len(b) < 2 || b[0] != '{' || b[len(b)-1] != '}' {
+		return nil, false
+	}
+	field := `"backend_latency_ms":` + strconv.FormatFloat(latency, 'f', -1, 64) + "}"
+	if len(b) > 2 {
+		field = "," + field
+	}
+	b = append(b[:len(b)-1], field...)
+	if !pretty {
+		return b, true
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, b, "", prettyIndent); err != nil {
+		return nil, false
+	}
+	return indented.Bytes(), true
+}
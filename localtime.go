@@ -0,0 +1,123 @@
+// localtime.go
+// The "get_local_time" tool.
+//
+// get_local_time returns the current local time, UTC offset and time zone of
+// a location, so agents can tell whether it is day or night there. The time
+// zone comes from the backend's /timezone endpoint. When the backend does
+// not know the location and it is given as coordinates (or geocoded to
+// them), the time zone is estimated from the longitude instead: one hour per
+// 15 degrees, which ignores political borders and daylight saving time.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newLocalTimeTool defines the "get_local_time" tool.
+func newLocalTimeTool() mcp.Tool {
+	return mcp.NewTool("get_local_time",
+		readOnlyAnnotation("Local Time", true),
+		mcp.WithDescription("Get the current local time, UTC offset and time zone for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name or coordinates of the location to get the local time for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// timezoneResponse is the backend's /timezone response.
+type timezoneResponse struct {
+	Location string `json:"location"`
+	// Timezone is an IANA time zone name, e.g. "Europe/Lisbon".
+	Timezone string `json:"timezone"`
+}
+
+// localTimeResult is the structured result of get_local_time.
+type localTimeResult struct {
+	Location string `json:"location"`
+	// Timezone is the IANA time zone name, or a fixed offset such as
+	// "UTC+01:00" when estimated.
+	Timezone     string    `json:"timezone"`
+	Abbreviation string    `json:"abbreviation"`
+	LocalTime    time.Time `json:"local_time"`
+	UTCOffset    string    `json:"utc_offset"`
+	// Source is "backend" or "estimated" (from the longitude).
+	Source string `json:"source"`
+	Stale  bool   `json:"stale,omitempty"`
+}
+
+// localTimeHandler handles incoming requests to the "get_local_time" tool.
+func localTimeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[localTimeHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	query.setParams(params)
+	result := localTimeResult{Location: location, Source: "backend"}
+	var loc *time.Location
+	staleNote := ""
+	backend, err := fetchBackend(ctx, "/timezone", params)
+	switch {
+	case err == nil:
+		var tz timezoneResponse
+		if err := json.Unmarshal(backend.Body, &tz); err != nil {
+			return nil, invalidResponsef("failed to parse time zone response: %w", err)
+		}
+		if tz.Timezone == "" {
+			break
+		}
+		if loc, err = time.LoadLocation(tz.Timezone); err != nil {
+			return nil, invalidResponsef("backend returned an unknown time zone %q", tz.Timezone)
+		}
+		result.Stale, staleNote = backend.Stale, backend.staleNote()
+	case !errors.Is(err, ErrNotFound):
+		return nil, err
+	}
+	if loc == nil {
+		if !query.isCoordinates() {
+			return nil, withClass(ErrNotFound, fmt.Errorf("no time zone is known for %s", query.Label))
+		}
+		logf(ctx, "[localTimeHandler] No time zone from the backend for %q, estimating from the longitude", query)
+		loc, result.Source = estimatedZone(query.Lon), "estimated"
+	}
+
+	now := time.Now().In(loc)
+	result.Timezone = loc.String()
+	result.Abbreviation, _ = now.Zone()
+	result.LocalTime = now.Truncate(time.Second)
+	result.UTCOffset = now.Format("-07:00")
+	text := fmt.Sprintf("Local time for %s: %s %s (%s, UTC%s)", query.Label,
+		now.Format("Mon 2006-01-02 15:04"), result.Abbreviation, result.Timezone, result.UTCOffset)
+	if result.Source == "estimated" {
+		text = fmt.Sprintf("Local time for %s: %s (%s) [estimated from the longitude]", query.Label,
+			now.Format("Mon 2006-01-02 15:04"), result.Timezone)
+	}
+	return newStructuredResult(text+staleNote, result, prettyArg(request))
+}
+
+// estimatedZone returns the nautical time zone of longitude lon: a fixed
+// offset of one hour per 15 degrees, named after it, e.g. "UTC+01:00".
+func estimatedZone(lon float64) *time.Location {
+	hours := int(math.Round(lon / 15))
+	return time.FixedZone(fmt.Sprintf("UTC%+03d:00", hours), hours*3600)
+}
@@ -0,0 +1,152 @@
+// location.go
+// Location handling shared by the tools.
+//
+// Every tool that takes a location resolves it the same way: an omitted
+// location falls back to DEFAULT_LOCATION, personal aliases are replaced by
+// the location they stand for, and text that looks like "lat,lon"
+// coordinates is sent to the backend as coordinates rather than as a name.
+// With GEOCODE_PATH set, names are geocoded to coordinates as well (see
+// geocode.go). With ALLOWED_LOCATIONS set, anything outside it is refused.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// locationArg returns the "location" argument, falling back to the configured
+// default location when it is omitted.
+func locationArg(args map[string]any) (string, error) {
+	location, _ := args["location"].(string)
+	if location == "" {
+		location = cfg.DefaultLocation
+	}
+	if location == "" {
+		return "", ErrLocationRequired
+	}
+	return location, nil
+}
+
+// locationQuery is a resolved location, ready to be sent to the backend.
+type locationQuery struct {
+	// Name is the place name sent as the "location" parameter. It is empty
+	// when the location is given as coordinates or as a station.
+	Name string
+	// Station is the weather station ID sent as the "station" parameter, for
+	// backends that key data by station.
+	Station string
+	// ID is the backend's numeric place ID sent as the "id" parameter, for
+	// backends that key places by ID.
+	ID int
+	// Lat and Lon are set when the location is given as coordinates.
+	Lat, Lon float64
+	// Label is how the location is shown in the output, noting any alias.
+	Label string
+	// Geocoded is the place name Lat and Lon were geocoded from, if any.
+	Geocoded string
+}
+
+// isCoordinates reports whether q is a coordinate query.
+func (q locationQuery) isCoordinates() bool {
+	return q.Name == "" && q.Station == "" && q.ID == 0
+}
+
+// stationQuery returns the query for weather station id. Stations bypass
+// alias, coordinate and geocoding resolution.
+func stationQuery(id string) locationQuery {
+	return locationQuery{Station: id, Label: "station " + id}
+}
+
+// idQuery returns the query for the backend's numeric place ID id. IDs
+// bypass alias, coordinate and geocoding resolution.
+func idQuery(id int) locationQuery {
+	return locationQuery{ID: id, Label: "location " + strconv.Itoa(id)}
+}
+
+// placeName returns the place name of q, including one it was geocoded
+// from, or "" for coordinates given as such.
+func (q locationQuery) placeName() string {
+	if q.isCoordinates() {
+		return q.Geocoded
+	}
+	return q.Name
+}
+
+// String returns the location as sent to the backend, for logging.
+func (q locationQuery) String() string {
+	switch {
+	case q.Station != "":
+		return "station " + q.Station
+	case q.ID != 0:
+		return "location " + strconv.Itoa(q.ID)
+	case q.isCoordinates():
+		return fmt.Sprintf("%g,%g", q.Lat, q.Lon)
+	}
+	return q.Name
+}
+
+// setParams adds the backend query parameters that identify q.
+func (q locationQuery) setParams(params url.Values) {
+	if q.Station != "" {
+		params.Set("station", q.Station)
+		return
+	}
+	if q.ID != 0 {
+		params.Set("id", strconv.Itoa(q.ID))
+		return
+	}
+	if q.isCoordinates() {
+		params.Set("lat", strconv.FormatFloat(q.Lat, 'f', -1, 64))
+		params.Set("lon", strconv.FormatFloat(q.Lon, 'f', -1, 64))
+		return
+	}
+	params.Set("location", q.Name)
+}
+
+// coordinatesPattern matches "lat,lon" text such as "48.85, 2.35".
+var coordinatesPattern = regexp.MustCompile(`^\s*([-+]?\d+(?:\.\d+)?)\s*,\s*([-+]?\d+(?:\.\d+)?)\s*$`)
+
+// resolveLocation turns a location argument into a backend query. Aliases
+// are applied first, so an alias may stand for a name or for coordinates.
+// With geocoding enabled, place names are resolved to coordinates.
+func resolveLocation(ctx context.Context, location string) (locationQuery, error) {
+	q := locationQuery{Name: location, Label: location}
+	target, isAlias := cfg.resolveAlias(location)
+	// An alias is allowed when either it or the location it stands for is.
+	if err := checkLocationAllowed(location, target); err != nil {
+		return locationQuery{}, err
+	}
+	if isAlias {
+		log.Printf("[resolveLocation] Resolved alias %q to %q", location, logCoordinates(target))
+		q = locationQuery{Name: target, Label: fmt.Sprintf("%s (alias for %s)", location, target)}
+	}
+	uniqueLocations.addLocation(q.Name)
+
+	m := coordinatesPattern.FindStringSubmatch(q.Name)
+	if m == nil {
+		if cfg.GeocodePath == "" {
+			return q, nil
+		}
+		lat, lon, err := geocode(ctx, q.Name)
+		if err != nil {
+			return locationQuery{}, err
+		}
+		q.Name, q.Lat, q.Lon, q.Geocoded = "", lat, lon, q.Name
+		return q, nil
+	}
+	lat, _ := strconv.ParseFloat(m[1], 64)
+	lon, _ := strconv.ParseFloat(m[2], 64)
+	if lat < -90 || lat > 90 {
+		return locationQuery{}, invalidArgumentf("latitude %g is out of range: must be between -90 and 90", lat)
+	}
+	if lon < -180 || lon > 180 {
+		return locationQuery{}, invalidArgumentf("longitude %g is out of range: must be between -180 and 180", lon)
+	}
+	q.Name, q.Lat, q.Lon = "", lat, lon
+	return q, nil
+}
@@ -0,0 +1,67 @@
+// logging.go
+// Helpers for what goes into the log file.
+//
+// The log is meant to be read by people, so anything of unbounded size is
+// trimmed before it is written. Exact coordinates can pinpoint a user's home,
+// so they are rounded to LOG_COORD_PRECISION decimals (2 by default, about a
+// kilometre) in everything logged; backend requests keep full precision.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// truncatedSuffix marks a logged value that was cut short.
+const truncatedSuffix = "...(truncated)"
+
+// logBody returns body for logging, truncated to the configured
+// LOG_BODY_LIMIT bytes (without splitting a UTF-8 character).
+func logBody(body []byte) string {
+	limit := cfg.LogBodyLimit
+	if len(body) <= limit {
+		return string(body)
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s%s (%d bytes)", body[:cut], truncatedSuffix, len(body))
+}
+
+// Coordinates as they appear in log lines: "lat=48.8566" query parameters,
+// "lat":48.8566 JSON fields and "48.8566,2.3522" pairs.
+var (
+	coordParamPattern = regexp.MustCompile(`\b((?:lat|lon)"?\s*[=:]\s*)([-+]?\d+\.\d+)`)
+	coordPairPattern  = regexp.MustCompile(`([-+]?\d{1,3}\.\d+)(\s*,\s*)([-+]?\d{1,3}\.\d+)`)
+)
+
+// logCoordinates returns s with the coordinates in it rounded to the
+// configured LOG_COORD_PRECISION decimals for logging.
+func logCoordinates(s string) string {
+	s = coordParamPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := coordParamPattern.FindStringSubmatch(m)
+		return sub[1] + roundCoordinate(sub[2])
+	})
+	return coordPairPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := coordPairPattern.FindStringSubmatch(m)
+		return roundCoordinate(sub[1]) + sub[2] + roundCoordinate(sub[3])
+	})
+}
+
+// roundCoordinate rounds a decimal coordinate to LOG_COORD_PRECISION
+// decimals. Values that are already short enough are returned unchanged.
+func roundCoordinate(v string) string {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return v
+	}
+	rounded := strconv.FormatFloat(f, 'f', cfg.LogCoordPrecision, 64)
+	if len(rounded) >= len(v) {
+		return v
+	}
+	return rounded
+}
@@ -9,21 +9,20 @@
 package main
 
 import (
-	"context"
-	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
+	// Embed the time zone database so local times work on hosts without one.
+	_ "time/tzdata"
 
-	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// init sends the log to its file. stdout is the protocol channel of the
+// stdio transport, so nothing but MCP messages may ever be written to it:
+// diagnostics go to the log, and only the errors that keep the server from
+// starting are also shown on stderr.
 func init() {
 	logDir := filepath.Join(os.Getenv("HOME"), "Library", "Logs", "mcp-temperature-server")
 	logFile := filepath.Join(logDir, "server.log")
@@ -39,97 +38,127 @@ func init() {
 	log.SetOutput(f)
 }
 
+// fatalf reports a startup error on stderr as well as in the log file, since
+// the log file is easy to miss when the server refuses to start, then exits.
+func fatalf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Fprintln(os.Stderr, msg)
+	log.Fatal(msg)
+}
+
+// serverName and serverVersion identify this server to MCP clients.
+const (
+	serverName    = "Temperature Service 🌡️"
+	serverVersion = "1.0.0"
+)
+
 func main() {
+	// Step 0: Load the configuration from the environment.
+	var err error
+	cfg, err = loadConfig()
+	if err != nil {
+		fatalf("[main] ERROR: %v", err)
+	}
+	httpClient = newHTTPClient(cfg)
+	if cache, err = newCache(cfg); err != nil {
+		fatalf("[main] ERROR: %v", err)
+	}
+	if geocodeCache, err = newGeocodeCache(cfg); err != nil {
+		fatalf("[main] ERROR: %v", err)
+	}
+	lastGood = newLastGoodStore(cfg)
+	recentFetches = newRecentFetchStore(cfg)
+	uniqueLocations = newUniqueLocations(cfg)
+	// Optionally hold off serving until the backend is up, so the first
+	// requests after an orchestrated start don't fail. A backend that never
+	// comes up is logged, not fatal: the tools report their own errors.
+	if cfg.StartupWait > 0 {
+		if err := waitForBackend(cfg.StartupWait, cfg.StartupWaitInterval); err != nil {
+			log.Printf("[main] WARNING: %v; serving anyway", err)
+		}
+	}
+
 	// Step 1: Create a new MCP server instance.
 	// The server will be named "Temperature Service 🌡️" and versioned as 1.0.0.
-	// The WithToolCapabilities(false) disables auto-discovery of tools (explicit registration only).
+	// WithToolCapabilities advertises tool-list change notifications only
+	// with TOOLS_LIST_CHANGED, as some clients behave differently with them.
 	s := server.NewMCPServer(
-		"Temperature Service 🌡️",
-		"1.0.0",
-		server.WithToolCapabilities(false),
+		serverName,
+		serverVersion,
+		server.WithToolCapabilities(cfg.ToolsListChanged),
+		server.WithResourceCapabilities(false, false),
+		// Clients that call logging/setLevel also get the log as notifications.
+		server.WithLogging(),
+		server.WithHooks(logLevelHooks()),
 	)
 
 	// Step 2: Define the "get_temperature" tool.
-	// This tool takes a single required string parameter: "location".
 	// The tool's description and parameter details are provided for discoverability and documentation.
-	tool := mcp.NewTool("get_temperature",
-		mcp.WithDescription("Get the temperature for a given location"),
-		mcp.WithString("location",
-			mcp.Required(),
-			mcp.Description("Name of the location to get the temperature for"),
-		),
-	)
+	tool := newTemperatureTool()
 
-	// Step 3: Register the tool and its handler with the MCP server.
+	// Step 3: Register the tools and their handlers with the MCP server.
 	// The handler function (temperatureHandler) will be called whenever the tool is invoked.
-	s.AddTool(tool, temperatureHandler)
-
-	// Step 4: Start the MCP server using stdio (standard input/output).
-	// This allows the server to communicate with clients via pipes or process integration.
-	if err := server.ServeStdio(s); err != nil {
-		fmt.Printf("Server error: %v\n", err)
-	}
-}
-
-// temperatureHandler handles incoming requests to the "get_temperature" tool.
-// It expects a "location" parameter and an optional "unit" parameter (defaults to "metric"), queries the underlying HTTP service, and returns the result.
-func temperatureHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Debug: Log received arguments
-	log.Printf("[temperatureHandler] Received Params: %+v", request.Params.Arguments)
-
-	// Extract the "location" argument from the request parameters.
-	location, ok := request.Params.Arguments["location"].(string)
-	if !ok || location == "" {
-		log.Println("[temperatureHandler] ERROR: location must be a non-empty string")
-		return nil, errors.New("location must be a non-empty string")
-	}
-
-	// Extract the optional "unit" argument, normalize to 'metric' or 'imperial', default to 'metric'.
-	unit, ok := request.Params.Arguments["unit"].(string)
-	if !ok || unit == "" {
-		unit = "metric"
-	} else {
-		switch u := strings.ToLower(unit); u {
-		case "celsius", "c", "metric":
-			unit = "metric"
-		case "fahrenheit", "f", "imperial":
-			unit = "imperial"
-		default:
-			unit = "metric"
+	// Registering the same tool name twice is a startup error rather than silent shadowing.
+	// Every handler is wrapped with the same in-flight tracking, logging, recovery,
+	// output limit, envelope, latency, concurrency limit, timeout, retry budget
+	// and cache bypass middleware.
+	registry := newToolRegistry(s, inFlightMiddleware, loggingMiddleware, recoveryMiddleware,
+		outputLimitMiddleware(cfg), envelopeMiddleware, latencyMiddleware, concurrencyLimitMiddleware(cfg),
+		timeoutMiddleware, retryBudgetMiddleware, freshMiddleware)
+	for _, t := range []server.ServerTool{
+		{Tool: tool, Handler: temperatureHandler},
+		{Tool: newTemperatureBatchTool(), Handler: temperatureBatchHandler},
+		{Tool: newForecastTool(), Handler: forecastHandler},
+		{Tool: newSunTimesTool(), Handler: sunTimesHandler},
+		{Tool: newAlertsTool(), Handler: alertsHandler},
+		{Tool: newUVIndexTool(), Handler: uvIndexHandler},
+		{Tool: newPressureTool(), Handler: pressureHandler},
+		{Tool: newCloudCoverTool(), Handler: cloudCoverHandler},
+		{Tool: newVisibilityTool(), Handler: visibilityHandler},
+		{Tool: newPrecipitationTool(), Handler: precipitationHandler},
+		{Tool: newSnowTool(), Handler: snowHandler},
+		{Tool: newWindTool(), Handler: windHandler},
+		{Tool: newMoonPhaseTool(), Handler: moonPhaseHandler},
+		{Tool: newLocalTimeTool(), Handler: localTimeHandler},
+		{Tool: newDescribeWeatherTool(), Handler: describeWeatherHandler},
+		{Tool: newConvertTool(), Handler: convertHandler},
+		{Tool: newServerInfoTool(), Handler: serverInfoHandler},
+		{Tool: newHealthCheckTool(), Handler: healthCheckHandler},
+	} {
+		if err := registry.add(t.Tool, t.Handler); err != nil {
+			fatalf("[main] ERROR: %v", err)
 		}
 	}
-
-	// Set the API key for authentication in the header
-	apiKey := os.Getenv("WEATHER_API_KEY")
-	if apiKey == "" {
-		log.Println("[temperatureHandler] WARNING: WEATHER_API_KEY is not set!")
+	// list_tools describes everything registered above, plus itself.
+	if err := registry.add(newListToolsTool(), registry.listToolsHandler); err != nil {
+		fatalf("[main] ERROR: %v", err)
 	}
-
-	// Step 1: Prepare the request URL for the HTTP temperature service.
-	endpoint := "http://localhost:8080/temperature"
-	reqUrl := fmt.Sprintf("%s?location=%s&units=%s&appid=%s", endpoint, url.QueryEscape(location), url.QueryEscape(unit), apiKey)
-	log.Printf("[temperatureHandler] Requesting URL: %s", reqUrl)
-
-	// Step 2: Make an HTTP GET request to the temperature service.
-	resp, err := http.Get(reqUrl)
-	if err != nil {
-		log.Printf("[temperatureHandler] ERROR: failed to query temperature service: %v", err)
-		return nil, fmt.Errorf("failed to query temperature service: %w", err)
+	// Per-tool timeouts are read now that the tool names are known.
+	if cfg.ToolTimeouts, err = parseToolTimeouts(os.Environ(), registry.names); err != nil {
+		fatalf("[main] ERROR: %v", err)
 	}
-	log.Printf("[temperatureHandler] HTTP response status: %s", resp.Status)
-	defer resp.Body.Close()
 
-	// Step 3: Check for a successful response.
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("temperature service returned status: %s", resp.Status)
+	// Step 3b: Expose the temperature as a templated resource for resource-first clients.
+	s.AddResourceTemplate(newTemperatureResourceTemplate(), temperatureResourceHandler)
+
+	// Step 3c: Keep the most frequently queried locations warm in the cache.
+	startCacheWarmer(cfg)
+
+	// Step 4: Start the MCP server on the configured transport.
+	switch cfg.Transport {
+	case "sse":
+		// SSE serves clients over HTTP, which also lets them receive streamed progress.
+		log.Printf("[main] Serving SSE on %s", cfg.SSEAddr)
+		err = serveSSE(s, cfg.SSEAddr)
+	default:
+		// stdio (standard input/output) allows the server to communicate with clients
+		// via pipes or process integration.
+		err = serveStdio(s)
 	}
-
-	// Step 4: Read the response body.
-	body, err := io.ReadAll(resp.Body)
+	// Exit non-zero on failure so supervisors notice and can restart the
+	// server.
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		log.Printf("[main] Server error: %v", err)
+		os.Exit(1)
 	}
-
-	// Step 5: Return the temperature result as plain text.
-	return mcp.NewToolResultText(fmt.Sprintf("Temperature for %s: %s", location, string(body))), nil
 }
@@ -0,0 +1,97 @@
+// main_test.go
+// Shared setup for the tests and benchmarks.
+
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// setupConfig loads the configuration from the environment, with env set on
+// top of it, and builds the globals main would, restoring them when tb ends.
+// The log is discarded, so tests don't fill the server's log file.
+func setupConfig(tb testing.TB, env map[string]string) {
+	tb.Helper()
+	for k, v := range env {
+		tb.Setenv(k, v)
+	}
+	saved := struct {
+		cfg             *config
+		httpClient      *http.Client
+		cache           responseCache
+		geocodeCache    responseCache
+		lastGood        *lastGoodStore
+		recentFetches   *recentFetchStore
+		uniqueLocations *hyperLogLog
+		output          io.Writer
+	}{cfg, httpClient, cache, geocodeCache, lastGood, recentFetches, uniqueLocations, log.Writer()}
+	tb.Cleanup(func() {
+		cfg, httpClient, cache, geocodeCache = saved.cfg, saved.httpClient, saved.cache, saved.geocodeCache
+		lastGood, recentFetches, uniqueLocations = saved.lastGood, saved.recentFetches, saved.uniqueLocations
+		log.SetOutput(saved.output)
+	})
+	log.SetOutput(io.Discard)
+
+	var err error
+	if cfg, err = loadConfig(); err != nil {
+		tb.Fatalf("loadConfig: %v", err)
+	}
+	httpClient = newHTTPClient(cfg)
+	if cache, err = newCache(cfg); err != nil {
+		tb.Fatalf("newCache: %v", err)
+	}
+	if geocodeCache, err = newGeocodeCache(cfg); err != nil {
+		tb.Fatalf("newGeocodeCache: %v", err)
+	}
+	lastGood = newLastGoodStore(cfg)
+	recentFetches = newRecentFetchStore(cfg)
+	uniqueLocations = newUniqueLocations(cfg)
+}
+
+// setupBackend starts a test backend serving handler and loads the
+// configuration pointing at it, as setupConfig does.
+func setupBackend(tb testing.TB, handler http.Handler, env map[string]string) *httptest.Server {
+	tb.Helper()
+	backend := httptest.NewServer(handler)
+	tb.Cleanup(backend.Close)
+	merged := map[string]string{"TEMPERATURE_API_ENDPOINT": backend.URL}
+	for k, v := range env {
+		merged[k] = v
+	}
+	setupConfig(tb, merged)
+	return backend
+}
+
+// jsonHandler answers every request with body as JSON.
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})
+}
+
+// toolRequest builds a tool call request with args.
+func toolRequest(args map[string]any) mcp.CallToolRequest {
+	var request mcp.CallToolRequest
+	request.Params.Arguments = args
+	return request
+}
+
+// resultText returns the text of the first content block of result.
+func resultText(tb testing.TB, result *mcp.CallToolResult) string {
+	tb.Helper()
+	if result == nil || len(result.Content) == 0 {
+		tb.Fatalf("empty result: %+v", result)
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		tb.Fatalf("first content block is %T, not text", result.Content[0])
+	}
+	return text.Text
+}
@@ -0,0 +1,209 @@
+// middleware.go
+// Tool handler middleware.
+//
+// Cross-cutting concerns (logging, panic recovery, timeouts, ...) are written
+// once as middleware and applied uniformly to every registered tool by the
+// tool registry, which keeps the individual handlers focused on their tool.
+
+package main
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolMiddleware wraps a tool handler with additional behavior.
+type toolMiddleware func(next server.ToolHandlerFunc) server.ToolHandlerFunc
+
+// chainMiddleware wraps handler with mws. The first middleware is the
+// outermost one, so it sees the request first and the result last.
+func chainMiddleware(handler server.ToolHandlerFunc, mws ...toolMiddleware) server.ToolHandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+// loggingMiddleware logs every tool call with its duration and outcome,
+// including the class of any error (see errorClass).
+func loggingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+		elapsed := time.Since(start).Round(time.Millisecond)
+		switch {
+		case err != nil:
+			logf(ctx, "[%s] ERROR (%s) after %s: %v", request.Params.Name, errorClass(err), elapsed, err)
+		case result != nil && result.IsError:
+			logf(ctx, "[%s] Returned an error result after %s", request.Params.Name, elapsed)
+		default:
+			logf(ctx, "[%s] Completed in %s", request.Params.Name, elapsed)
+		}
+		return result, err
+	}
+}
+
+// inFlightMiddleware counts running tool calls for graceful shutdown, and
+// cancels them when its grace period runs out (see shutdownSSE).
+func inFlightMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		inFlight.wg.Add(1)
+		inFlight.count.Add(1)
+		defer func() {
+			inFlight.count.Add(-1)
+			inFlight.wg.Done()
+		}()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(forceCloseCtx, cancel)
+		defer stop()
+		return next(ctx, request)
+	}
+}
+
+// recoveryMiddleware keeps a panic in one handler from taking down the whole
+// stdio server: it logs the panic with its stack trace and answers that one
+// request with an error result instead.
+func recoveryMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logf(ctx, "[%s] PANIC: %v\n%s", request.Params.Name, r, debug.Stack())
+				result, err = mcp.NewToolResultError("internal error in "+request.Params.Name), nil
+			}
+		}()
+		return next(ctx, request)
+	}
+}
+
+// outputLimitMiddleware cuts results down to MAX_OUTPUT_CHARS characters
+// (see limitOutput).
+func outputLimitMiddleware(c *config) toolMiddleware {
+	if c.MaxOutputChars <= 0 {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc { return next }
+	}
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil {
+				return result, err
+			}
+			limited := limitOutput(result, c.MaxOutputChars)
+			if limited != result {
+				logf(ctx, "[%s] Output truncated to MAX_OUTPUT_CHARS (%d)", request.Params.Name, c.MaxOutputChars)
+			}
+			return limited, nil
+		}
+	}
+}
+
+// envelopeMiddleware wraps the JSON output of calls that set "envelope" in
+// a metadata envelope (see wrapEnvelope).
+func envelopeMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !mcp.ParseBoolean(request, "envelope", false) {
+			return next(ctx, request)
+		}
+		requestedAt := time.Now()
+		ctx, stats := withCallStats(ctx)
+		result, err := next(ctx, request)
+		if err != nil {
+			return result, err
+		}
+		return wrapEnvelope(result, requestedAt, stats, prettyArg(request))
+	}
+}
+
+// freshMiddleware makes the backend fetches of calls that set "fresh" skip
+// the cache.
+func freshMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if mcp.ParseBoolean(request, "fresh", false) {
+			ctx = withFresh(ctx)
+		}
+		return next(ctx, request)
+	}
+}
+
+// latencyMiddleware reports the backend latency of calls that set
+// "include_latency" (see addLatency).
+func latencyMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !mcp.ParseBoolean(request, "include_latency", false) {
+			return next(ctx, request)
+		}
+		ctx, stats := withCallStats(ctx)
+		result, err := next(ctx, request)
+		if err != nil {
+			return result, err
+		}
+		return addLatency(result, stats, prettyArg(request)), nil
+	}
+}
+
+// retryBudgetMiddleware gives each tool call a RETRY_BUDGET shared by all of
+// its backend requests.
+func retryBudgetMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if cfg.RetryBudget > 0 {
+			ctx = withRetryBudget(ctx, cfg.RetryBudget)
+		}
+		return next(ctx, request)
+	}
+}
+
+// concurrencyLimitMiddleware caps the number of tool handlers running at
+// once at MAX_CONCURRENT_CALLS. When every slot is taken, a call either
+// waits up to BUSY_QUEUE_TIMEOUT for one (BUSY_POLICY=queue) or is rejected
+// straight away (BUSY_POLICY=reject) with a busy error result.
+func concurrencyLimitMiddleware(c *config) toolMiddleware {
+	if c.MaxConcurrentCalls <= 0 {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc { return next }
+	}
+	slots := make(chan struct{}, c.MaxConcurrentCalls)
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !acquireSlot(ctx, slots, c.BusyPolicy, c.BusyQueueTimeout) {
+				logf(ctx, "[%s] WARNING: rejected, %d calls already running", request.Params.Name, c.MaxConcurrentCalls)
+				return mcp.NewToolResultError("server is busy, try again shortly"), nil
+			}
+			defer func() { <-slots }()
+			return next(ctx, request)
+		}
+	}
+}
+
+// acquireSlot takes a slot from slots, waiting up to wait under the "queue"
+// policy. It reports whether a slot was taken.
+func acquireSlot(ctx context.Context, slots chan struct{}, policy string, wait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if policy != "queue" {
+		return false
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// timeoutMiddleware makes the backend requests of each tool use its
+// configured timeout (TIMEOUT_<TOOL>, falling back to BACKEND_TIMEOUT).
+func timeoutMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(withBackendTimeout(ctx, cfg.timeoutFor(request.Params.Name)), request)
+	}
+}
@@ -0,0 +1,30 @@
+// middleware_test.go
+// Tests of the tool handler middleware.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	setupConfig(t, nil)
+	panicking := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		panic("boom")
+	}
+	request := toolRequest(nil)
+	request.Params.Name = "get_temperature"
+	result, err := recoveryMiddleware(panicking)(context.Background(), request)
+	if err != nil {
+		t.Fatalf("recoveryMiddleware returned error %v, want an error result", err)
+	}
+	if !result.IsError {
+		t.Errorf("result is not an error: %+v", result)
+	}
+	if text := resultText(t, result); text != "internal error in get_temperature" {
+		t.Errorf("result text = %q, want %q", text, "internal error in get_temperature")
+	}
+}
@@ -0,0 +1,109 @@
+// minrefresh.go
+// The cache bypass and the minimum refresh interval.
+//
+// Tools that fetch from the backend accept "fresh", which skips the cache and
+// always asks the backend. For metered backends MIN_REFRESH_INTERVAL puts a
+// hard floor under that: a query that really reached the backend less than
+// the interval ago is answered with that response instead, whether or not
+// the call asked for fresh data and whatever the cache holds. The recent
+// responses are kept in their own small store, so the guarantee holds with
+// the cache disabled, evicted or told not to cache by Cache-Control; only
+// responses marked no-store are never kept, here or anywhere else.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// freshOption declares the "fresh" argument of tools that fetch from the backend.
+func freshOption() mcp.ToolOption {
+	return mcp.WithBoolean("fresh",
+		mcp.Description("Skip the cache and fetch from the backend; the same query is still not sent more often than the server's MIN_REFRESH_INTERVAL"),
+	)
+}
+
+// freshKey is the context key set for calls that asked for fresh data.
+type freshKey struct{}
+
+// withFresh returns a context whose backend fetches skip the cache.
+func withFresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshKey{}, true)
+}
+
+// freshRequested reports whether ctx's tool call asked for fresh data.
+func freshRequested(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshKey{}).(bool)
+	return fresh
+}
+
+// recentFetches holds the responses fetched within MIN_REFRESH_INTERVAL, or
+// nil when it is off.
+var recentFetches *recentFetchStore
+
+// recentFetch is a backend response and when it was fetched.
+type recentFetch struct {
+	body      []byte
+	fetchedAt time.Time
+}
+
+// recentFetchStore remembers, per cache key, the last response fetched from
+// the backend for as long as the interval. A nil store remembers nothing.
+type recentFetchStore struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	entries map[string]recentFetch
+}
+
+// newRecentFetchStore returns the store for c, or nil when
+// MIN_REFRESH_INTERVAL is off.
+func newRecentFetchStore(c *config) *recentFetchStore {
+	if c.MinRefreshInterval <= 0 {
+		return nil
+	}
+	return &recentFetchStore{interval: c.MinRefreshInterval, entries: make(map[string]recentFetch)}
+}
+
+// get returns the response fetched for key less than the interval before now.
+func (s *recentFetchStore) get(key string, now time.Time) (recentFetch, bool) {
+	if s == nil {
+		return recentFetch{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || now.Sub(entry.fetchedAt) >= s.interval {
+		return recentFetch{}, false
+	}
+	return entry, true
+}
+
+// set records body as fetched for key at fetchedAt, dropping the entries
+// whose interval has passed.
+func (s *recentFetchStore) set(key string, body []byte, fetchedAt time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, e := range s.entries {
+		if fetchedAt.Sub(e.fetchedAt) >= s.interval {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = recentFetch{body: body, fetchedAt: fetchedAt}
+}
+
+// status describes the interval for server_info.
+func (s *recentFetchStore) status() string {
+	if s == nil {
+		return "disabled"
+	}
+	return fmt.Sprintf("%s per query", s.interval)
+}
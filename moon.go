@@ -0,0 +1,141 @@
+// moon.go
+// The "get_moon_phase" tool.
+//
+// get_moon_phase returns the moon phase name and illuminated fraction for a
+// date. By default it is computed locally from the moon's mean synodic
+// month, which is accurate to within a few hours and the same everywhere on
+// Earth. With MOON_PATH set (e.g. /moon), the backend is asked instead, for
+// the given location and date.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// synodicMonth is the mean length of a lunar cycle, in days.
+const synodicMonth = 29.530588853
+
+// referenceNewMoon is a known new moon, from which phases are counted.
+var referenceNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// moonPhases names the eight phases, each spanning an eighth of the cycle
+// centred on its principal point, starting from the new moon.
+var moonPhases = []string{
+	"New Moon", "Waxing Crescent", "First Quarter", "Waxing Gibbous",
+	"Full Moon", "Waning Gibbous", "Last Quarter", "Waning Crescent",
+}
+
+// newMoonPhaseTool defines the "get_moon_phase" tool.
+func newMoonPhaseTool() mcp.Tool {
+	return mcp.NewTool("get_moon_phase",
+		readOnlyAnnotation("Moon Phase", cfg.MoonPath != ""),
+		mcp.WithDescription("Get the moon phase and the illuminated fraction of the moon for a date"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the moon phase for, when the server asks its backend (MOON_PATH); the computed phase is the same everywhere"),
+		),
+		mcp.WithString("date",
+			mcp.Description("Date to get the moon phase for, as YYYY-MM-DD (defaults to today)"),
+		),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// moonReading is the backend's /moon response.
+type moonReading struct {
+	Location string `json:"location"`
+	// Phase is the phase name, e.g. "Waxing Gibbous".
+	Phase string `json:"phase"`
+	// Illumination is the illuminated fraction of the moon, from 0 to 1.
+	Illumination *float64 `json:"illumination"`
+}
+
+// moonPhaseResult is the structured result of get_moon_phase.
+type moonPhaseResult struct {
+	Location string `json:"location,omitempty"`
+	Date     string `json:"date"`
+	Phase    string `json:"phase"`
+	// Illumination is the illuminated fraction of the moon, from 0 to 1.
+	Illumination float64 `json:"illumination"`
+	// Source is "computed" or "backend".
+	Source string `json:"source"`
+	Stale  bool   `json:"stale,omitempty"`
+}
+
+// moonPhaseHandler handles incoming requests to the "get_moon_phase" tool.
+func moonPhaseHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[moonPhaseHandler] Received Params: %+v", request.Params.Arguments)
+
+	date := time.Now().UTC()
+	if raw := mcp.ParseString(request, "date", ""); raw != "" {
+		var err error
+		if date, err = time.Parse(dateLayout, raw); err != nil {
+			return nil, invalidArgumentf("date must be formatted as YYYY-MM-DD, got %q", raw)
+		}
+		// Report the phase as of midday, halfway through the date.
+		date = date.Add(12 * time.Hour)
+	}
+	result := moonPhaseResult{Date: date.Format(dateLayout), Source: "computed"}
+
+	heading := "Moon phase on " + result.Date
+	staleNote := ""
+	if cfg.MoonPath == "" {
+		result.Phase, result.Illumination = moonPhase(date)
+	} else {
+		location, err := locationArg(request.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		query, err := resolveLocation(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		params := url.Values{}
+		query.setParams(params)
+		params.Set("date", result.Date)
+		backend, err := fetchBackend(ctx, cfg.MoonPath, params)
+		if err != nil {
+			return nil, err
+		}
+		var reading moonReading
+		if err := json.Unmarshal(backend.Body, &reading); err != nil {
+			return nil, invalidResponsef("failed to parse moon phase response: %w", err)
+		}
+		if reading.Phase == "" || reading.Illumination == nil {
+			return nil, invalidResponsef("moon phase response is missing phase or illumination")
+		}
+		if *reading.Illumination < 0 || *reading.Illumination > 1 {
+			return nil, invalidResponsef("backend returned an invalid moon illumination of %g", *reading.Illumination)
+		}
+		result.Location, result.Source, result.Stale = location, "backend", backend.Stale
+		result.Phase, result.Illumination = reading.Phase, *reading.Illumination
+		heading = fmt.Sprintf("Moon phase for %s on %s", query.Label, result.Date)
+		staleNote = backend.staleNote()
+	}
+
+	text := fmt.Sprintf("%s: %s, %s%% illuminated", heading, result.Phase,
+		numberFormat{Precision: 0}.format(result.Illumination*100))
+	return newStructuredResult(text+staleNote, result, prettyArg(request))
+}
+
+// moonPhase computes the phase name and illuminated fraction of the moon at t.
+func moonPhase(t time.Time) (name string, illumination float64) {
+	age := math.Mod(t.Sub(referenceNewMoon).Hours()/24, synodicMonth)
+	if age < 0 {
+		age += synodicMonth
+	}
+	cycle := age / synodicMonth
+	illumination = (1 - math.Cos(2*math.Pi*cycle)) / 2
+	index := int(math.Floor(cycle*8+0.5)) % len(moonPhases)
+	return moonPhases[index], math.Round(illumination*1000) / 1000
+}
@@ -0,0 +1,95 @@
+// nonfinite.go
+// NaN and Infinity in backend responses.
+//
+// JSON has no NaN or Infinity, but some backends write them anyway, as bare
+// tokens (NaN, -Infinity), for a location without data. They would otherwise
+// fail the whole response, so temperature responses are passed through
+// nullNonFinite first, which turns such values into null: the reading then
+// has no temperature and is reported as unavailable, like any other missing
+// value. Strings are data, not numbers, and are never touched: a "NaN"
+// location name stays as it is.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// nonFiniteTokens are the spellings of NaN and Infinity that nullNonFinite
+// replaces, compared case-insensitively.
+var nonFiniteTokens = []string{"nan", "-nan", "infinity", "-infinity", "+infinity", "inf", "-inf", "+inf"}
+
+// isNonFinite reports whether s spells NaN or Infinity.
+func isNonFinite(s string) bool {
+	for _, token := range nonFiniteTokens {
+		if strings.EqualFold(s, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// nullNonFinite returns body with every bare NaN or Infinity value replaced
+// by null, and whether it replaced any. Strings, keys included, are copied
+// as they are, whatever they spell.
+func nullNonFinite(body []byte) ([]byte, bool) {
+	var out bytes.Buffer
+	replaced := false
+	// afterValueStart is true where a value may begin: after ':', '[' or ','.
+	afterValueStart := false
+	for i := 0; i < len(body); {
+		c := body[i]
+		switch {
+		case c == '"':
+			end := stringEnd(body, i)
+			out.Write(body[i:end])
+			i, afterValueStart = end, false
+		case afterValueStart && (c == '-' || c == '+' || c == 'N' || c == 'n' || c == 'I' || c == 'i'):
+			end := i + 1
+			for end < len(body) && isTokenByte(body[end]) {
+				end++
+			}
+			if token := string(body[i:end]); isNonFinite(token) {
+				out.WriteString("null")
+				replaced = true
+			} else {
+				out.WriteString(token)
+			}
+			i, afterValueStart = end, false
+		default:
+			out.WriteByte(c)
+			switch c {
+			case ':', '[', ',':
+				afterValueStart = true
+			case ' ', '\t', '\r', '\n':
+			default:
+				afterValueStart = false
+			}
+			i++
+		}
+	}
+	if !replaced {
+		return body, false
+	}
+	return out.Bytes(), true
+}
+
+// stringEnd returns the index just past the JSON string starting at
+// body[start], or len(body) when it is unterminated.
+func stringEnd(body []byte, start int) int {
+	for i := start + 1; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(body)
+}
+
+// isTokenByte reports whether c can continue a bare token such as -Infinity.
+func isTokenByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '+' || c == '-'
+}
@@ -0,0 +1,85 @@
+// nonfinite_test.go
+// Tests of the NaN and Infinity handling of backend responses.
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNullNonFinite(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		want     string
+		replaced bool
+	}{
+		{"bare NaN", `{"temperature":NaN}`, `{"temperature":null}`, true},
+		{"bare Infinity", `{"temperature": Infinity}`, `{"temperature": null}`, true},
+		{"bare -Infinity", `{"temperature":-Infinity,"humidity":50}`, `{"temperature":null,"humidity":50}`, true},
+		{"in an array", `{"recent":[1.5, nan ,2]}`, `{"recent":[1.5, null ,2]}`, true},
+		{"quoted NaN", `{"temperature":"NaN"}`, `{"temperature":"NaN"}`, false},
+		{"key named NaN", `{"NaN":1,"Infinity":[2]}`, `{"NaN":1,"Infinity":[2]}`, false},
+		{"escaped quotes", `{"location":"say \"NaN\", then: NaN","temperature":NaN}`, `{"location":"say \"NaN\", then: NaN","temperature":null}`, true},
+		{"escaped backslash", `{"location":"C:\\","temperature":Infinity}`, `{"location":"C:\\","temperature":null}`, true},
+		{"negative number", `{"temperature":-12.5e1}`, `{"temperature":-12.5e1}`, false},
+		{"literals", `{"a":null,"b":true,"c":false}`, `{"a":null,"b":true,"c":false}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replaced := nullNonFinite([]byte(tt.body))
+			if string(got) != tt.want || replaced != tt.replaced {
+				t.Errorf("nullNonFinite(%s) = %s, %t; want %s, %t", tt.body, got, replaced, tt.want, tt.replaced)
+			}
+		})
+	}
+}
+
+func TestUnavailableTemperature(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		env  map[string]string
+	}{
+		{"null", `{"location":"Lisbon","temperature":null}`, nil},
+		{"NaN", `{"location":"Lisbon","temperature":NaN}`, nil},
+		{"missing", `{"location":"Lisbon"}`, nil},
+		// A body over the threshold goes through the streaming decoder,
+		// which stops at the NaN and reads the rest as is.
+		{"streamed NaN", `{"location":"Lisbon","temperature":NaN,"humidity":` + strings.Repeat("1", 8) + `}`, map[string]string{"BACKEND_STREAM_THRESHOLD": "16"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupBackend(t, jsonHandler(tt.body), tt.env)
+			result, err := temperatureHandler(context.Background(), toolRequest(map[string]any{"location": "Lisbon"}))
+			if err != nil {
+				t.Fatalf("temperatureHandler: %v", err)
+			}
+			want := "Temperature for Lisbon: " + cfg.UnavailableMessage
+			if text := resultText(t, result); text != want {
+				t.Errorf("text = %q, want %q", text, want)
+			}
+		})
+	}
+
+	// A quoted NaN is a string, not a missing number.
+	setupBackend(t, jsonHandler(`{"location":"Lisbon","temperature":"NaN"}`), nil)
+	if _, err := temperatureHandler(context.Background(), toolRequest(map[string]any{"location": "Lisbon"})); err == nil {
+		t.Error("temperatureHandler accepted a string temperature")
+	}
+}
+
+func TestReadBodyStreamed(t *testing.T) {
+	body := `{"location":"Lisbon","temperature":18.5}`
+	setupBackend(t, jsonHandler(body+"\n"), map[string]string{"BACKEND_STREAM_THRESHOLD": "16"})
+	resp, err := fetchBackend(context.Background(), "/temperature", url.Values{"location": {"Lisbon"}})
+	if err != nil {
+		t.Fatalf("fetchBackend: %v", err)
+	}
+	if string(resp.Body) != body {
+		t.Errorf("streamed body = %q, want %q", resp.Body, body)
+	}
+}
@@ -0,0 +1,152 @@
+// precipitation.go
+// The "get_precipitation" tool.
+//
+// get_precipitation returns the chance of precipitation and the expected
+// amount for a location, queried from the backend's /precip endpoint, either
+// for now or over a forecast window of the next "hours" hours. The backend
+// reports millimetres; imperial requests are converted locally to inches.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// mmPerInch is the number of millimetres in one inch.
+const mmPerInch = 25.4
+
+// inchesPrecision is the default number of decimals for converted inch
+// values, the precision rain gauges report in.
+const inchesPrecision = 2
+
+// maxPrecipitationHours is the longest forecast window get_precipitation accepts.
+const maxPrecipitationHours = 48
+
+// newPrecipitationTool defines the "get_precipitation" tool.
+func newPrecipitationTool() mcp.Tool {
+	return mcp.NewTool("get_precipitation",
+		readOnlyAnnotation("Precipitation", true),
+		mcp.WithDescription("Get the chance of precipitation and the expected amount for a given location, now or over the next hours"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the precipitation for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithNumber("hours",
+			mcp.Description("Forecast window in hours, from 1 to 48; defaults to the current conditions"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system: metric for millimetres (the default) or imperial for inches"),
+		),
+		formatOptions("the value as reported for mm amounts and 2 for inches"),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// precipitationReading is the backend's /precip response.
+type precipitationReading struct {
+	Location string `json:"location"`
+	// Probability is the chance of precipitation, in percent.
+	Probability *float64 `json:"probability"`
+	// Amount is the expected precipitation, in millimetres.
+	Amount *float64 `json:"amount"`
+}
+
+// precipitationResult is the structured result of get_precipitation.
+type precipitationResult struct {
+	Location string `json:"location"`
+	// Hours is the forecast window, or 0 for the current conditions.
+	Hours       int      `json:"hours,omitempty"`
+	Available   bool     `json:"available"`
+	Probability *float64 `json:"probability,omitempty"`
+	Amount      *float64 `json:"amount,omitempty"`
+	// Unit is the unit of Amount, "mm" or "in".
+	Unit  string `json:"unit"`
+	Stale bool   `json:"stale,omitempty"`
+}
+
+// precipitationHandler handles incoming requests to the "get_precipitation" tool.
+func precipitationHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[precipitationHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	hours := mcp.ParseInt(request, "hours", 0)
+	if _, ok := request.Params.Arguments["hours"]; ok && (hours < 1 || hours > maxPrecipitationHours) {
+		return nil, invalidArgumentf("hours must be between 1 and %d, got %d", maxPrecipitationHours, hours)
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := normalizeUnit(mcp.ParseString(request, "unit", ""))
+	if err != nil {
+		return nil, err
+	}
+	imperial := unit == "imperial"
+
+	var extra url.Values
+	if hours > 0 {
+		extra = url.Values{"hours": {strconv.Itoa(hours)}}
+	}
+	var reading precipitationReading
+	backend, err := fetchMetricReading(ctx, query, "/precip", extra, "precipitation", &reading)
+	if err != nil {
+		return nil, err
+	}
+
+	result := precipitationResult{Location: location, Hours: hours, Unit: "mm", Stale: backend.Stale}
+	if imperial {
+		result.Unit = "in"
+	}
+	window := "now"
+	if hours > 0 {
+		window = fmt.Sprintf("over the next %d hours", hours)
+	}
+	if reading.Probability == nil && reading.Amount == nil {
+		text := fmt.Sprintf("Precipitation for %s %s: no precipitation data is available for this location", query.Label, window)
+		return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+	}
+	result.Available = true
+
+	var parts []string
+	if p := reading.Probability; p != nil {
+		if *p < 0 || *p > 100 {
+			return nil, invalidResponsef("backend returned an invalid precipitation probability of %g%%", *p)
+		}
+		probability := numberFormat{Precision: 0}.round(*p)
+		result.Probability = &probability
+		parts = append(parts, fmt.Sprintf("%s%% chance", numberFormat{Precision: 0}.format(probability)))
+	}
+	if a := reading.Amount; a != nil {
+		if *a < 0 {
+			return nil, invalidResponsef("backend returned a negative precipitation amount of %g mm", *a)
+		}
+		value := *a
+		amountFormat := format
+		if imperial {
+			value /= mmPerInch
+			if amountFormat.Precision < 0 {
+				amountFormat.Precision = inchesPrecision
+			}
+		}
+		value = amountFormat.round(value)
+		result.Amount = &value
+		parts = append(parts, fmt.Sprintf("%s %s expected", amountFormat.format(value), result.Unit))
+	}
+	text := fmt.Sprintf("Precipitation for %s %s: %s", query.Label, window, strings.Join(parts, ", "))
+	return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+}
@@ -0,0 +1,108 @@
+// pressure.go
+// The "get_pressure" tool.
+//
+// get_pressure returns the atmospheric pressure for a location, queried from
+// the backend's /pressure endpoint. The backend reports hectopascals; imperial
+// requests are converted locally to inches of mercury.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// hPaPerInHg is the number of hectopascals in one inch of mercury.
+const hPaPerInHg = 33.8639
+
+// inHgPrecision is the default number of decimals for converted inHg values,
+// the precision altimeter settings are given in.
+const inHgPrecision = 2
+
+// newPressureTool defines the "get_pressure" tool.
+func newPressureTool() mcp.Tool {
+	return mcp.NewTool("get_pressure",
+		readOnlyAnnotation("Air Pressure", true),
+		mcp.WithDescription("Get the atmospheric (barometric) pressure for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the pressure for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system: metric for hPa (the default) or imperial for inHg"),
+		),
+		formatOptions("the value as reported for hPa and 2 for inHg"),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// pressureReading is the backend's /pressure response.
+type pressureReading struct {
+	Location string `json:"location"`
+	// Pressure is in hectopascals.
+	Pressure *float64 `json:"pressure"`
+}
+
+// pressureResult is the structured result of get_pressure.
+type pressureResult struct {
+	Location  string   `json:"location"`
+	Available bool     `json:"available"`
+	Pressure  *float64 `json:"pressure,omitempty"`
+	// Unit is "hPa" or "inHg".
+	Unit  string `json:"unit"`
+	Stale bool   `json:"stale,omitempty"`
+}
+
+// pressureHandler handles incoming requests to the "get_pressure" tool.
+func pressureHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[pressureHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := normalizeUnit(mcp.ParseString(request, "unit", ""))
+	if err != nil {
+		return nil, err
+	}
+	imperial := unit == "imperial"
+
+	var reading pressureReading
+	backend, err := fetchMetricReading(ctx, query, "/pressure", nil, "pressure", &reading)
+	if err != nil {
+		return nil, err
+	}
+
+	result := pressureResult{Location: location, Unit: "hPa", Stale: backend.Stale}
+	if imperial {
+		result.Unit = "inHg"
+	}
+	if reading.Pressure == nil {
+		text := fmt.Sprintf("Pressure for %s: no pressure data is available for this location", query.Label)
+		return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+	}
+	value := *reading.Pressure
+	if imperial {
+		value /= hPaPerInHg
+		if format.Precision < 0 {
+			format.Precision = inHgPrecision
+		}
+	}
+	value = format.round(value)
+	result.Available = true
+	result.Pressure = &value
+	text := fmt.Sprintf("Pressure for %s: %s %s", query.Label, format.format(value), result.Unit)
+	return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+}
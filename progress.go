@@ -0,0 +1,61 @@
+// progress.go
+// Progress notifications for long-running tool calls.
+//
+// When a client attaches a progress token to a request, combined queries
+// report each location as soon as its backend call completes, so streaming
+// clients (e.g. over SSE) can show partial results before the full response.
+
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// progressReporter sends notifications/progress for one request. A nil
+// reporter, returned when the client did not ask for progress, does nothing.
+type progressReporter struct {
+	ctx   context.Context
+	token mcp.ProgressToken
+	total int
+
+	mu   sync.Mutex
+	done int
+}
+
+// newProgressReporter returns a reporter for request, which is expected to
+// complete total steps, or nil if the request carries no progress token.
+func newProgressReporter(ctx context.Context, request mcp.CallToolRequest, total int) *progressReporter {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	return &progressReporter{ctx: ctx, token: request.Params.Meta.ProgressToken, total: total}
+}
+
+// step records one completed step and notifies the client with message.
+func (p *progressReporter) step(message string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+
+	srv := server.ServerFromContext(p.ctx)
+	if srv == nil {
+		return
+	}
+	err := srv.SendNotificationToClient(p.ctx, "notifications/progress", map[string]any{
+		"progressToken": p.token,
+		"progress":      p.done,
+		"total":         p.total,
+		"message":       message,
+	})
+	if err != nil {
+		log.Printf("[progressReporter] WARNING: failed to send progress notification: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// registry.go
+// Tool registration.
+//
+// mcp-go silently replaces a tool when a second one is added under the same
+// name. toolRegistry sits in front of the server and turns that into a clear
+// startup error, so a conditionally added tool can never shadow another one.
+// It also remembers what was registered, which backs the "list_tools" tool.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolRegistry registers tools with an MCP server, rejecting duplicate names.
+type toolRegistry struct {
+	server      *server.MCPServer
+	middlewares []toolMiddleware
+	tools       []server.ServerTool
+	names       map[string]bool
+}
+
+// newToolRegistry returns an empty registry for s that wraps every handler
+// it registers with mws.
+func newToolRegistry(s *server.MCPServer, mws ...toolMiddleware) *toolRegistry {
+	return &toolRegistry{server: s, middlewares: mws, names: make(map[string]bool)}
+}
+
+// add registers tool and its handler, or returns an error if a tool with the
+// same name has already been registered.
+func (r *toolRegistry) add(tool mcp.Tool, handler server.ToolHandlerFunc) error {
+	if r.names[tool.Name] {
+		return fmt.Errorf("tool %q is already registered", tool.Name)
+	}
+	r.names[tool.Name] = true
+	r.tools = append(r.tools, server.ServerTool{Tool: tool, Handler: handler})
+	r.server.AddTool(tool, chainMiddleware(handler, r.middlewares...))
+	return nil
+}
+
+// readOnlyAnnotation marks a tool as read-only and idempotent: none of this
+// server's tools change anything, so clients may call them freely and retry
+// them. openWorld is false for tools that never reach the backend.
+func readOnlyAnnotation(title string, openWorld bool) mcp.ToolOption {
+	return mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		Title:           title,
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   openWorld,
+	})
+}
+
+// newListToolsTool defines the "list_tools" tool. It only takes "pretty".
+func newListToolsTool() mcp.Tool {
+	return mcp.NewTool("list_tools",
+		readOnlyAnnotation("List Tools", false),
+		mcp.WithDescription("List every tool this server provides, with its description and parameter schema"),
+		prettyOption(),
+	)
+}
+
+// listToolsHandler handles incoming requests to the "list_tools" tool. It is
+// meant for gateways that do not forward the protocol's own tools/list.
+func (r *toolRegistry) listToolsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var b strings.Builder
+	tools := make([]mcp.Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		tools = append(tools, t.Tool)
+		fmt.Fprintf(&b, "- %s: %s\n", t.Tool.Name, t.Tool.Description)
+		params := make([]string, 0, len(t.Tool.InputSchema.Properties))
+		for name := range t.Tool.InputSchema.Properties {
+			params = append(params, name)
+		}
+		sort.Strings(params)
+		if len(params) > 0 {
+			fmt.Fprintf(&b, "  parameters: %s\n", strings.Join(params, ", "))
+		}
+	}
+	return newStructuredResult(strings.TrimRight(b.String(), "\n"), map[string]any{"tools": tools}, prettyArg(request))
+}
@@ -0,0 +1,78 @@
+// resources.go
+// MCP resources.
+//
+// Resource-first clients can read the temperature as a resource instead of
+// calling a tool, using templated URIs such as
+// weather://temperature/Chapel%20Hill?unit=imperial. The location and unit
+// are taken from the URI and the same query logic as get_temperature is used.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// temperatureURITemplate is the URI template of the temperature resource.
+const temperatureURITemplate = "weather://temperature/{location}{?unit}"
+
+// newTemperatureResourceTemplate defines the temperature resource template.
+func newTemperatureResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(temperatureURITemplate, "Temperature",
+		mcp.WithTemplateDescription("Current temperature for a location; add ?unit=imperial for Fahrenheit"),
+		mcp.WithTemplateMIMEType("text/plain"),
+	)
+}
+
+// temperatureResourceHandler reads a weather://temperature/{location} resource.
+func temperatureResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	logf(ctx, "[temperatureResourceHandler] Reading %s", request.Params.URI)
+
+	location, unit, err := parseTemperatureURI(request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnitSupported(normalized); err != nil {
+		return nil, err
+	}
+	result, err := temperatureFor(ctx, location, temperatureOptions{
+		Unit:   normalized,
+		Format: numberFormat{Precision: -1},
+		Lang:   cfg.DefaultLang,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/plain",
+			Text:     result.Text,
+		},
+	}, nil
+}
+
+// parseTemperatureURI extracts the location and optional unit from a URI of
+// the form weather://temperature/{location}?unit={unit}.
+func parseTemperatureURI(uri string) (location, unit string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", invalidArgumentf("invalid resource URI %q: %w", uri, err)
+	}
+	if u.Scheme != "weather" || u.Host != "temperature" {
+		return "", "", invalidArgumentf("unsupported resource URI %q: expected weather://temperature/{location}", uri)
+	}
+	location = strings.Trim(u.Path, "/")
+	if location == "" {
+		return "", "", withClass(ErrLocationRequired, fmt.Errorf("resource URI %q does not name a location", uri))
+	}
+	return location, u.Query().Get("unit"), nil
+}
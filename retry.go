@@ -0,0 +1,180 @@
+// retry.go
+// Retry policy for backend requests.
+//
+// Only failures that are likely to go away on their own are retried:
+// transient DNS resolution errors, network timeouts and gateway-style 5xx
+// responses. A refused connection means nothing is listening, so retrying it
+// only delays the error.
+//
+// Only idempotent requests are retried, since repeating a request that
+// changes state on the backend could apply it twice. Every backend request is
+// currently a GET; should a POST mode be added, its requests are retried only
+// with RETRY_POST set.
+//
+// Retries are also bounded in time: every tool call gets a RETRY_BUDGET, shared
+// by all the backend requests it makes, and no retry is started that would
+// begin after the budget or the call's own deadline has run out.
+//
+// A slow backend may just need more time, so with RETRY_TIMEOUT_FACTOR each
+// retry may wait longer than the attempt before it: with a 3s timeout and a
+// factor of 2, the attempts get 3s, 6s, 12s, ... An escalated timeout never
+// runs past the retry budget, and the first attempt keeps the tool's timeout.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Retry policy defaults, overridden by RETRY_MAX_ATTEMPTS, RETRY_BASE_DELAY
+// and RETRY_MAX_DELAY.
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 200 * time.Millisecond
+	defaultRetryMaxDelay    = 2 * time.Second
+)
+
+// defaultRetryTimeoutFactor is the default RETRY_TIMEOUT_FACTOR: every
+// attempt gets the same timeout.
+const defaultRetryTimeoutFactor = 1.0
+
+// maxRetryAttempts caps RETRY_MAX_ATTEMPTS; more attempts than this only
+// hammer a backend that is clearly down.
+const maxRetryAttempts = 10
+
+// maxRetryTimeoutFactor caps RETRY_TIMEOUT_FACTOR; a backend that needs more
+// than ten times as long on each retry is down, not slow.
+const maxRetryTimeoutFactor = 10.0
+
+// statusError reports a non-200 response from the backend.
+type statusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("temperature service returned status: %s", e.Status)
+}
+
+// Unwrap classifies the status: 429 is ErrRateLimited, 404 ErrNotFound and
+// any 5xx ErrBackendUnavailable.
+func (e *statusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode >= 500:
+		return ErrBackendUnavailable
+	default:
+		return nil
+	}
+}
+
+// methodRetryable reports whether requests with method may be retried: GET
+// and HEAD always, POST only when RETRY_POST opts in, anything else never.
+func methodRetryable(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return cfg.RetryPost
+	default:
+		return false
+	}
+}
+
+// isRetryable reports whether a failed backend request is worth retrying.
+func isRetryable(err error) bool {
+	// The caller went away: stop immediately. A passed deadline looks like
+	// an attempt timing out; retryAllowed stops retrying at the deadline.
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// DNS hiccups are usually transient, even when reported as "not found"
+	// by a flaky resolver.
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	// Nothing is listening on the other side; retrying will not help.
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// retryBudgetKey is the context key holding the retry deadline of the current tool call.
+type retryBudgetKey struct{}
+
+// withRetryBudget returns a context whose backend requests stop retrying
+// once d has passed.
+func withRetryBudget(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, time.Now().Add(d))
+}
+
+// retryAllowed reports whether a retry started after delay would still fall
+// within the retry budget and the deadline of ctx.
+func retryAllowed(ctx context.Context, delay time.Duration) bool {
+	next := time.Now().Add(delay)
+	if deadline, ok := ctx.Value(retryBudgetKey{}).(time.Time); ok && next.After(deadline) {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && next.After(deadline) {
+		return false
+	}
+	return true
+}
+
+// backoffDelay returns the wait before retry number attempt (starting at 1),
+// doubling from RETRY_BASE_DELAY up to RETRY_MAX_DELAY.
+func backoffDelay(attempt int) time.Duration {
+	delay := cfg.RetryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > cfg.RetryMaxDelay {
+		return cfg.RetryMaxDelay
+	}
+	return delay
+}
+
+// attemptTimeout returns the timeout of attempt number attempt (starting at
+// 1): the backend timeout of ctx, multiplied by RETRY_TIMEOUT_FACTOR for each
+// retry. An escalated timeout is cut to what is left of the retry budget,
+// but never below the first attempt's, and saturates rather than overflows.
+func attemptTimeout(ctx context.Context, attempt int) time.Duration {
+	base := backendTimeout(ctx)
+	scaled := float64(base) * math.Pow(cfg.RetryTimeoutFactor, float64(attempt-1))
+	if scaled <= float64(base) {
+		return base
+	}
+	timeout := time.Duration(math.MaxInt64)
+	if scaled < float64(math.MaxInt64) {
+		timeout = time.Duration(scaled)
+	}
+	if deadline, ok := ctx.Value(retryBudgetKey{}).(time.Time); ok {
+		timeout = min(timeout, max(base, time.Until(deadline)))
+	}
+	return timeout
+}
@@ -0,0 +1,96 @@
+// retry_test.go
+// Tests of the backend retry policy.
+
+package main
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// failingTransport fails every request with err, counting them.
+type failingTransport struct {
+	err   error
+	calls atomic.Int32
+}
+
+func (t *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	t.calls.Add(1)
+	return nil, t.err
+}
+
+func TestFetchRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int32
+	}{
+		{"temporary DNS error", &net.DNSError{Err: "server misbehaving", Name: "backend", IsTemporary: true}, 3},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupConfig(t, map[string]string{"RETRY_MAX_ATTEMPTS": "3", "RETRY_BASE_DELAY": "1ms", "RETRY_MAX_DELAY": "1ms"})
+			transport := &failingTransport{err: tt.err}
+			httpClient = &http.Client{Transport: transport}
+			if _, err := fetchBackend(context.Background(), "/temperature", url.Values{"location": {"Lisbon"}}); err == nil {
+				t.Fatal("fetchBackend succeeded")
+			}
+			if got := transport.calls.Load(); got != tt.wantCalls {
+				t.Errorf("backend called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	setupConfig(t, map[string]string{"BACKEND_TIMEOUT": "10s", "RETRY_TIMEOUT_FACTOR": "10"})
+	ctx := context.Background()
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 100 * time.Second},
+		{3, 1000 * time.Second},
+		// 10s × 10⁹ is past the largest Duration.
+		{10, math.MaxInt64},
+		{100, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if got := attemptTimeout(ctx, tt.attempt); got != tt.want {
+			t.Errorf("attemptTimeout(attempt %d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+
+	// The retry budget still bounds an escalated timeout.
+	budget := withRetryBudget(ctx, 30*time.Second)
+	if got := attemptTimeout(budget, 10); got > 30*time.Second || got < 29*time.Second {
+		t.Errorf("attemptTimeout with a 30s budget = %s, want about 30s", got)
+	}
+}
+
+func TestRetryTimeoutFactorClamped(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"0.5", 1},
+		{"2", 2},
+		{"1e6", maxRetryTimeoutFactor},
+	}
+	for _, tt := range tests {
+		setupConfig(t, map[string]string{"RETRY_TIMEOUT_FACTOR": tt.value})
+		if cfg.RetryTimeoutFactor != tt.want {
+			t.Errorf("RETRY_TIMEOUT_FACTOR=%s: factor %g, want %g", tt.value, cfg.RetryTimeoutFactor, tt.want)
+		}
+	}
+}
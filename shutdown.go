@@ -0,0 +1,60 @@
+// shutdown.go
+// Graceful shutdown of the SSE transport.
+//
+// mcp-go runs SSE tool calls in goroutines detached from their HTTP request,
+// so stopping the HTTP server does not wait for them. inFlightMiddleware
+// counts them instead. On SIGTERM or SIGINT the server stops accepting
+// connections, keeps the open event streams so running calls can still
+// deliver their results, and gives those calls SHUTDOWN_GRACE to finish.
+// Whatever is left then is cancelled through its context, and logged.
+
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shutdownFlushDelay is how long the event streams stay open once every call
+// has finished: mcp-go writes a result to its stream just after the handler
+// returns.
+const shutdownFlushDelay = 100 * time.Millisecond
+
+// inFlight tracks the tool calls currently running.
+var inFlight struct {
+	wg    sync.WaitGroup
+	count atomic.Int64
+}
+
+// forceCloseCtx is cancelled when the shutdown grace period runs out, which
+// cancels every tool call still in flight.
+var forceCloseCtx, forceClose = context.WithCancel(context.Background())
+
+// shutdownSSE stops srv, waiting up to grace for in-flight tool calls before
+// cancelling the rest.
+func shutdownSSE(srv *http.Server, grace time.Duration) {
+	log.Printf("[shutdownSSE] Shutting down, waiting up to %s for %d in-flight requests", grace, inFlight.count.Load())
+	// Shutdown closes the listeners at once, then waits for the event
+	// streams, which only end with the Close below.
+	go srv.Shutdown(context.Background())
+
+	drained := make(chan struct{})
+	go func() {
+		inFlight.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		log.Printf("[shutdownSSE] All requests finished")
+		time.Sleep(shutdownFlushDelay)
+	case <-time.After(grace):
+		log.Printf("[shutdownSSE] WARNING: shutdown grace period of %s expired with %d requests still in flight; cancelling them",
+			grace, inFlight.count.Load())
+		forceClose()
+	}
+	srv.Close()
+}
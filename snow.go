@@ -0,0 +1,136 @@
+// snow.go
+// The "get_snow" tool.
+//
+// get_snow returns the current snow depth and the snowfall of the last 24
+// hours for a location, from the backend's /snow endpoint, for ski and
+// winter-travel agents. The backend reports centimetres; imperial requests
+// are converted locally to inches.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// cmPerInch is the number of centimetres in one inch.
+const cmPerInch = 2.54
+
+// snowInchesPrecision is the default number of decimals for converted inch
+// values, the precision snow reports use.
+const snowInchesPrecision = 1
+
+// newSnowTool defines the "get_snow" tool.
+func newSnowTool() mcp.Tool {
+	return mcp.NewTool("get_snow",
+		readOnlyAnnotation("Snow", true),
+		mcp.WithDescription("Get the current snow depth and the snowfall of the last 24 hours for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the snow conditions for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system: metric for centimetres (the default) or imperial for inches"),
+		),
+		formatOptions("the value as reported for cm and 1 for inches"),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// snowReading is the backend's /snow response.
+type snowReading struct {
+	Location string `json:"location"`
+	// Depth is the snow on the ground, in centimetres.
+	Depth *float64 `json:"depth"`
+	// Snowfall is the new snow of the last 24 hours, in centimetres.
+	Snowfall *float64 `json:"snowfall"`
+}
+
+// snowResult is the structured result of get_snow.
+type snowResult struct {
+	Location  string   `json:"location"`
+	Available bool     `json:"available"`
+	Depth     *float64 `json:"depth,omitempty"`
+	Snowfall  *float64 `json:"snowfall_24h,omitempty"`
+	// Unit is the unit of Depth and Snowfall, "cm" or "in".
+	Unit  string `json:"unit"`
+	Stale bool   `json:"stale,omitempty"`
+}
+
+// snowHandler handles incoming requests to the "get_snow" tool.
+func snowHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[snowHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := normalizeUnit(mcp.ParseString(request, "unit", ""))
+	if err != nil {
+		return nil, err
+	}
+	imperial := unit == "imperial"
+
+	var reading snowReading
+	backend, err := fetchMetricReading(ctx, query, "/snow", nil, "snow", &reading)
+	if err != nil {
+		return nil, err
+	}
+
+	result := snowResult{Location: location, Unit: "cm", Stale: backend.Stale}
+	if imperial {
+		result.Unit = "in"
+		if format.Precision < 0 {
+			format.Precision = snowInchesPrecision
+		}
+	}
+	if reading.Depth == nil && reading.Snowfall == nil {
+		text := fmt.Sprintf("Snow for %s: no snow data is available for this location", query.Label)
+		return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+	}
+	result.Available = true
+
+	// measure validates a backend value in centimetres and converts it to
+	// the requested unit.
+	measure := func(name string, cm float64) (float64, error) {
+		if cm < 0 {
+			return 0, invalidResponsef("backend returned a negative snow %s of %g cm", name, cm)
+		}
+		if imperial {
+			cm /= cmPerInch
+		}
+		return format.round(cm), nil
+	}
+	var parts []string
+	if d := reading.Depth; d != nil {
+		value, err := measure("depth", *d)
+		if err != nil {
+			return nil, err
+		}
+		result.Depth = &value
+		parts = append(parts, fmt.Sprintf("%s %s on the ground", format.format(value), result.Unit))
+	}
+	if s := reading.Snowfall; s != nil {
+		value, err := measure("snowfall", *s)
+		if err != nil {
+			return nil, err
+		}
+		result.Snowfall = &value
+		parts = append(parts, fmt.Sprintf("%s %s of new snow in the last 24 hours", format.format(value), result.Unit))
+	}
+	text := fmt.Sprintf("Snow for %s: %s", query.Label, strings.Join(parts, ", "))
+	return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+}
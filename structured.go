@@ -0,0 +1,67 @@
+// structured.go
+// Structured tool results.
+//
+// Tools return a human-readable text block followed by a JSON block holding
+// the same data, so programmatic clients don't have to parse the prose.
+// JSON is compact unless the request sets "pretty", which indents it.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// prettyIndent is the indentation used for pretty JSON.
+const prettyIndent = "  "
+
+// prettyOption declares the "pretty" argument of tools that return JSON.
+func prettyOption() mcp.ToolOption {
+	return mcp.WithBoolean("pretty",
+		mcp.Description("Indent the JSON output for readability; defaults to compact"),
+	)
+}
+
+// prettyArg reads the "pretty" argument.
+func prettyArg(request mcp.CallToolRequest) bool {
+	return mcp.ParseBoolean(request, "pretty", false)
+}
+
+// marshalJSON encodes v compactly, or indented when pretty is set.
+func marshalJSON(v any, pretty bool) ([]byte, error) {
+	if pretty {
+		return json.MarshalIndent(v, "", prettyIndent)
+	}
+	return json.Marshal(v)
+}
+
+// indentRawJSON indents a JSON document received from the backend when
+// pretty is set. Anything that is not valid JSON is returned unchanged.
+func indentRawJSON(raw string, pretty bool) string {
+	if !pretty {
+		return raw
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(raw), "", prettyIndent); err != nil {
+		return raw
+	}
+	return b.String()
+}
+
+// newStructuredResult returns a result with text followed by data encoded as
+// JSON, indented when pretty is set.
+func newStructuredResult(text string, data any, pretty bool) (*mcp.CallToolResult, error) {
+	b, err := marshalJSON(data, pretty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode structured result: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(text),
+			mcp.NewTextContent(string(b)),
+		},
+	}, nil
+}
@@ -0,0 +1,97 @@
+// sun.go
+// The "get_sun_times" tool.
+//
+// get_sun_times returns sunrise and sunset for a location, queried from the
+// backend's /sun endpoint, in the location's local time zone and in UTC.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// dateLayout is the format of the optional "date" argument.
+const dateLayout = "2006-01-02"
+
+// newSunTimesTool defines the "get_sun_times" tool.
+func newSunTimesTool() mcp.Tool {
+	return mcp.NewTool("get_sun_times",
+		readOnlyAnnotation("Sunrise and Sunset", true),
+		mcp.WithDescription("Get the sunrise and sunset times for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the sun times for"),
+		),
+		mcp.WithString("date",
+			mcp.Description("Date to get the sun times for, as YYYY-MM-DD (defaults to today)"),
+		),
+	)
+}
+
+// sunTimes is the backend's /sun response.
+type sunTimes struct {
+	Location string    `json:"location"`
+	Timezone string    `json:"timezone"`
+	Sunrise  time.Time `json:"sunrise"`
+	Sunset   time.Time `json:"sunset"`
+}
+
+// sunTimesHandler handles incoming requests to the "get_sun_times" tool.
+func sunTimesHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[sunTimesHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	label := query.Label
+
+	params := url.Values{}
+	query.setParams(params)
+	if date := mcp.ParseString(request, "date", ""); date != "" {
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return nil, invalidArgumentf("date must be formatted as YYYY-MM-DD, got %q", date)
+		}
+		params.Set("date", date)
+	}
+
+	backend, err := fetchBackend(ctx, "/sun", params)
+	if err != nil {
+		return nil, err
+	}
+	var times sunTimes
+	if err := json.Unmarshal(backend.Body, &times); err != nil {
+		return nil, invalidResponsef("failed to parse sun times response: %w", err)
+	}
+	if times.Sunrise.IsZero() || times.Sunset.IsZero() {
+		return nil, invalidResponsef("sun times response is missing sunrise or sunset")
+	}
+
+	// Show the times in the location's own time zone when the backend names
+	// one; otherwise keep the offset the timestamps were reported with.
+	loc := times.Sunrise.Location()
+	if times.Timezone != "" {
+		if tz, err := time.LoadLocation(times.Timezone); err == nil {
+			loc = tz
+		} else {
+			logf(ctx, "[sunTimesHandler] WARNING: unknown time zone %q: %v", times.Timezone, err)
+		}
+	}
+	sunrise, sunset := times.Sunrise.In(loc), times.Sunset.In(loc)
+
+	return mcp.NewToolResultText(fmt.Sprintf(
+		"Sun times for %s on %s:\nSunrise: %s (%s UTC)\nSunset: %s (%s UTC)",
+		label, sunrise.Format(dateLayout),
+		sunrise.Format("15:04 MST"), sunrise.UTC().Format("15:04"),
+		sunset.Format("15:04 MST"), sunset.UTC().Format("15:04"),
+	) + backend.staleNote()), nil
+}
@@ -0,0 +1,732 @@
+// temperature.go
+// The "get_temperature" tool.
+//
+// get_temperature proxies a location (or a list of locations) to the HTTP
+// temperature service. Combined queries run concurrently and return whatever
+// succeeded, together with an explicit note about the locations that failed.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newTemperatureTool defines the "get_temperature" tool.
+// It takes a "location" string or, for a combined query, a "locations" array.
+func newTemperatureTool() mcp.Tool {
+	return mcp.NewTool("get_temperature",
+		readOnlyAnnotation("Current Temperature", true),
+		mcp.WithDescription("Get the temperature for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the temperature for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithArray("locations",
+			mcp.Description(fmt.Sprintf("Several locations to query at once (at most %d), instead of a single location", maxBatchItems)),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("station_id",
+			mcp.Description("ID of a specific weather station to query, instead of a location"),
+		),
+		mcp.WithNumber("location_id",
+			mcp.Description("The backend's numeric ID of the place to query (a positive integer), instead of a location"),
+		),
+		mcp.WithBoolean("auto_locate",
+			mcp.Description("When no location is given, use the location of the client's IP address"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system: metric (celsius), imperial (fahrenheit) or kelvin, or a list of them such as [\"metric\", \"imperial\"] to report in each; defaults to metric"),
+			stringOrList(),
+		),
+		mcp.WithBoolean("auto_unit",
+			mcp.Description("When no unit is given, use the local convention of the location's country (imperial for the US, metric elsewhere)"),
+		),
+		mcp.WithString("time",
+			mcp.Description("RFC3339 timestamp of a past reading to fetch instead of the current temperature"),
+		),
+		mcp.WithNumber("precision",
+			mcp.Description("Number of decimals to show (0-6); defaults to the value as reported"),
+		),
+		mcp.WithString("rounding",
+			mcp.Description("How to round to the precision: round (half to even, the default), floor or ceil; on its own it rounds to whole numbers"),
+			mcp.Enum("round", "floor", "ceil"),
+		),
+		mcp.WithBoolean("include_trend",
+			mcp.Description("Also report whether the temperature is rising, falling or steady over the last hour"),
+		),
+		mcp.WithBoolean("include_dew_point",
+			mcp.Description("Also report the dew point, from the backend or computed from temperature and humidity"),
+		),
+		mcp.WithBoolean("include_category",
+			mcp.Description("Also classify the apparent (feels-like, or else actual) temperature as freezing, cold, comfortable, hot or dangerous heat, for health advisories"),
+		),
+		mcp.WithBoolean("include_source",
+			mcp.Description("Also report which provider supplied the reading, when the backend says"),
+		),
+		mcp.WithBoolean("include_emoji",
+			mcp.Description("Prefix the output with an emoji for the current weather conditions, when the backend reports them"),
+		),
+		mcp.WithBoolean("raw",
+			mcp.Description("Also return the backend's unmodified JSON response, for debugging"),
+		),
+		langOption(),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// temperatureHandler handles incoming requests to the "get_temperature" tool.
+// It expects a "location" parameter (or a "locations" array, a "station_id" or a "location_id") and an optional "unit" parameter (defaults to "metric"), queries the underlying HTTP service, and returns the result.
+func temperatureHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Debug: Log received arguments
+	logf(ctx, "[temperatureHandler] Received Params: %+v", request.Params.Arguments)
+
+	// Extract the "station_id", "location_id", "location" or "locations" argument from the request parameters.
+	direct, directName, isDirect, err := directQueryArg(request.Params.Arguments)
+	if err != nil {
+		logf(ctx, "[temperatureHandler] ERROR: %v", err)
+		return nil, err
+	}
+	autoLocate := !isDirect && !hasLocationArgs(request.Params.Arguments) &&
+		mcp.ParseBoolean(request, "auto_locate", cfg.AutoLocate)
+	var locations []string
+	if !isDirect && !autoLocate {
+		if locations, err = requestedLocations(request.Params.Arguments); err != nil {
+			logf(ctx, "[temperatureHandler] ERROR: %v", err)
+			return nil, err
+		}
+	}
+
+	// Extract the optional "unit" argument, normalized to 'metric' or 'imperial',
+	// and the output flags.
+	at, err := timeArg(request)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	lang, err := langArg(request)
+	if err != nil {
+		return nil, err
+	}
+
+	units, unitGiven, err := unitsArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	for _, unit := range units {
+		if err := checkUnitSupported(unit); err != nil {
+			return nil, err
+		}
+	}
+	if len(units) > 1 && len(locations) > 1 {
+		return nil, invalidArgumentf("a list of units can only be used with a single location")
+	}
+	opts := temperatureOptions{
+		At:              at,
+		Format:          format,
+		Lang:            lang,
+		Unit:            units[0],
+		AutoUnit:        !unitGiven && mcp.ParseBoolean(request, "auto_unit", cfg.AutoUnit),
+		IncludeTrend:    mcp.ParseBoolean(request, "include_trend", false),
+		IncludeDew:      mcp.ParseBoolean(request, "include_dew_point", false),
+		IncludeEmoji:    mcp.ParseBoolean(request, "include_emoji", false),
+		IncludeSource:   mcp.ParseBoolean(request, "include_source", false),
+		IncludeCategory: mcp.ParseBoolean(request, "include_category", false),
+		Raw:             mcp.ParseBoolean(request, "raw", false),
+		Pretty:          prettyArg(request),
+	}
+
+	if isDirect || autoLocate || len(locations) == 1 {
+		single := func(opts temperatureOptions) (temperatureResult, error) {
+			switch {
+			case isDirect:
+				return temperatureForQuery(ctx, directName, direct, opts)
+			case autoLocate:
+				return temperatureHere(ctx, opts)
+			default:
+				return temperatureFor(ctx, locations[0], opts)
+			}
+		}
+		if len(units) > 1 {
+			return multiUnitTemperature(units, opts, single)
+		}
+		result, err := single(opts)
+		if err != nil {
+			return nil, err
+		}
+		out, err := newStructuredResult(result.Text, result, opts.Pretty)
+		if err != nil {
+			return nil, err
+		}
+		return appendRaw(out, opts.Pretty, result), nil
+	}
+
+	// Combined query: fetch the locations concurrently, at most
+	// BATCH_CONCURRENCY at once like a batch, and keep per-item errors,
+	// streaming each result as a progress notification when the client asks for it.
+	results := make([]temperatureResult, len(locations))
+	errs := make([]error, len(locations))
+	progress := newProgressReporter(ctx, request, len(locations))
+	slots := make(chan struct{}, cfg.BatchConcurrency)
+	var wg sync.WaitGroup
+	for i, location := range locations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				results[i] = failedResult(location, errs[i])
+				return
+			}
+			results[i], errs[i] = safeTemperatureFor(ctx, location, opts)
+			if errs[i] != nil {
+				results[i] = failedResult(location, errs[i])
+			}
+			progress.step(results[i].Text)
+		}()
+	}
+	wg.Wait()
+	return combinedResult(results, errs, opts.Pretty)
+}
+
+// temperatureOptions controls how a temperature query is made and reported.
+type temperatureOptions struct {
+	// Unit is the normalized unit system to report in.
+	Unit string
+	// AutoUnit picks the unit from the location's country instead of Unit.
+	AutoUnit bool
+	// IncludeTrend appends the rising/falling/steady trend to the output.
+	IncludeTrend bool
+	// IncludeDew appends the dew point to the output.
+	IncludeDew bool
+	// IncludeEmoji prefixes the output with a weather condition emoji.
+	IncludeEmoji bool
+	// IncludeSource appends the provider that supplied the reading.
+	IncludeSource bool
+	// IncludeCategory appends the apparent temperature category.
+	IncludeCategory bool
+	// Raw also returns the backend's unmodified JSON response.
+	Raw bool
+	// Pretty indents the JSON output.
+	Pretty bool
+	// At requests a historical reading instead of the current one.
+	At time.Time
+	// Format controls the precision and rounding of the reported value.
+	Format numberFormat
+	// Lang is the language asked of the backend; empty sends none.
+	Lang string
+}
+
+// temperatureReading is the subset of the backend's JSON response the server
+// understands. Unknown fields are ignored.
+type temperatureReading struct {
+	Location    string   `json:"location"`
+	Temperature *float64 `json:"temperature"`
+	// Trend is the backend's own trend assessment, if it provides one.
+	Trend string `json:"trend,omitempty"`
+	// Humidity is the relative humidity in percent, if reported.
+	Humidity *float64 `json:"humidity,omitempty"`
+	// DewPoint is the backend's dew point, in the requested unit, if reported.
+	DewPoint *float64 `json:"dew_point,omitempty"`
+	// FeelsLike is the backend's apparent temperature, in the requested
+	// unit, if reported.
+	FeelsLike *float64 `json:"feels_like,omitempty"`
+	// Source is the provider that supplied the reading, for backends that
+	// aggregate several, e.g. "NOAA".
+	Source string `json:"source,omitempty"`
+	// Conditions is the backend's weather conditions code, e.g. "rain".
+	Conditions string `json:"conditions,omitempty"`
+	// Country is the location's country, as a name or ISO code, if reported.
+	Country string `json:"country,omitempty"`
+	// Recent holds recent samples, used to compute a trend when the backend
+	// does not report one directly.
+	Recent []temperatureSample `json:"recent,omitempty"`
+	// Time is when the reading was observed, as reported by the backend.
+	Time *time.Time `json:"time,omitempty"`
+	// Available lets the backend state explicitly that it has no data.
+	Available *bool `json:"available,omitempty"`
+}
+
+// toKelvin converts a reading fetched in metric units to Kelvin.
+func (r *temperatureReading) toKelvin() {
+	if r.Temperature != nil {
+		k := celsiusToKelvin(*r.Temperature)
+		r.Temperature = &k
+	}
+	if r.DewPoint != nil {
+		k := celsiusToKelvin(*r.DewPoint)
+		r.DewPoint = &k
+	}
+	if r.FeelsLike != nil {
+		k := celsiusToKelvin(*r.FeelsLike)
+		r.FeelsLike = &k
+	}
+	for i := range r.Recent {
+		if t := r.Recent[i].Temperature; t != nil {
+			k := celsiusToKelvin(*t)
+			r.Recent[i].Temperature = &k
+		}
+	}
+}
+
+// hasData reports whether the reading actually carries a temperature.
+// Backends signal "no data" (e.g. over open ocean) with a null or missing
+// temperature, or with "available": false.
+func (r temperatureReading) hasData() bool {
+	if r.Available != nil && !*r.Available {
+		return false
+	}
+	return r.Temperature != nil
+}
+
+// timeArg parses the optional RFC3339 "time" argument. Future times are
+// rejected unless the backend is configured as supporting forecasts.
+func timeArg(request mcp.CallToolRequest) (time.Time, error) {
+	raw := mcp.ParseString(request, "time", "")
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, invalidArgumentf("time must be an RFC3339 timestamp such as 2024-01-02T15:04:05Z, got %q", raw)
+	}
+	if at.After(time.Now()) && !cfg.BackendForecasts {
+		return time.Time{}, invalidArgumentf("time %s is in the future; the backend only serves past readings", raw)
+	}
+	return at, nil
+}
+
+// temperatureResult is the outcome of a query for one location. It is
+// returned to clients as structured JSON next to the formatted Text.
+type temperatureResult struct {
+	Location string `json:"location"`
+	// Available is false when the backend has no temperature for the location.
+	Available   bool     `json:"available"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	Unit        string   `json:"unit"`
+	Trend       string   `json:"trend,omitempty"`
+	DewPoint    *float64 `json:"dew_point,omitempty"`
+	// Source is the provider that supplied the reading, when asked for.
+	Source string `json:"source,omitempty"`
+	// Category is the apparent temperature category, when asked for;
+	// FeelsLike is the backend's apparent temperature it was based on, if any.
+	Category  string   `json:"category,omitempty"`
+	FeelsLike *float64 `json:"feels_like,omitempty"`
+	// Reference is the reading in REFERENCE_UNIT, when one is configured.
+	Reference *referenceReading `json:"reference,omitempty"`
+	// Emoji is the conditions emoji prefixed to Text, when asked for and known.
+	Emoji string `json:"emoji,omitempty"`
+	// StationID is the weather station queried, when one was asked for.
+	StationID string `json:"station_id,omitempty"`
+	// LocationID is the backend place ID queried, when one was asked for.
+	LocationID int `json:"location_id,omitempty"`
+	// ObservedAt is the backend's timestamp for the reading, if it reported one.
+	ObservedAt *time.Time `json:"observed_at,omitempty"`
+	// Stale is set when the reading came from the cache because the backend
+	// was unavailable; CachedAt is when it was fetched.
+	Stale    bool       `json:"stale,omitempty"`
+	CachedAt *time.Time `json:"cached_at,omitempty"`
+	// Error explains why a location in a combined query has no result.
+	Error string `json:"error,omitempty"`
+
+	Text string `json:"-"`
+	// Label is how the location is named in Text.
+	Label string `json:"-"`
+	// Raw is the backend's unmodified response body, kept when requested.
+	Raw string `json:"-"`
+}
+
+// referenceReading is a reading converted to REFERENCE_UNIT, so stored
+// results share one unit whatever each client displays.
+type referenceReading struct {
+	Temperature float64  `json:"temperature"`
+	DewPoint    *float64 `json:"dew_point,omitempty"`
+	Unit        string   `json:"unit"`
+}
+
+// referencePrecision is the number of decimals reference values are rounded
+// to, which hides conversion noise without losing measured precision.
+const referencePrecision = 2
+
+// toReference converts value, in unit, to REFERENCE_UNIT.
+func toReference(value float64, unit string) float64 {
+	return numberFormat{Precision: referencePrecision}.round(fromCelsius(toCelsius(value, unit), cfg.ReferenceUnit))
+}
+
+// requestedLocations returns the locations named by the "location" or
+// "locations" arguments. At most one of the two may be provided, and
+// "locations" holds at most maxBatchItems entries, as a batch does; when
+// neither is, the configured default location is used.
+func requestedLocations(args map[string]any) ([]string, error) {
+	list, hasList := args["locations"].([]any)
+	if !hasList {
+		location, err := locationArg(args)
+		if err != nil {
+			return nil, err
+		}
+		return []string{location}, nil
+	}
+	if location, _ := args["location"].(string); location != "" {
+		return nil, invalidArgumentf("provide either location or locations, not both")
+	}
+	var locations []string
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			return nil, invalidArgumentf("locations must contain only non-empty strings")
+		}
+		locations = append(locations, s)
+	}
+	if len(locations) == 0 {
+		return nil, invalidArgumentf("locations must not be empty")
+	}
+	if len(locations) > maxBatchItems {
+		return nil, invalidArgumentf("locations has %d entries, at most %d are allowed", len(locations), maxBatchItems)
+	}
+	return locations, nil
+}
+
+// hasLocationArgs reports whether a "location" or "locations" argument was given.
+func hasLocationArgs(args map[string]any) bool {
+	location, _ := args["location"].(string)
+	_, hasLocations := args["locations"]
+	return location != "" || hasLocations
+}
+
+// directQueryArg returns the query for a "station_id" or "location_id"
+// argument, along with the argument as given; ok is false when neither is.
+// Both are alternatives to a location, so at most one of "location",
+// "locations", "station_id" and "location_id" may be given.
+func directQueryArg(args map[string]any) (query locationQuery, name string, ok bool, err error) {
+	given := 0
+	for _, key := range []string{"locations", "station_id", "location_id"} {
+		if _, ok := args[key]; ok {
+			given++
+		}
+	}
+	if location, _ := args["location"].(string); location != "" {
+		given++
+	}
+	if given > 1 {
+		return locationQuery{}, "", false, invalidArgumentf("provide exactly one of location, locations, station_id or location_id")
+	}
+
+	if raw, ok := args["station_id"]; ok {
+		station, _ := raw.(string)
+		if station = strings.TrimSpace(station); station == "" {
+			return locationQuery{}, "", false, invalidArgumentf("station_id must be a non-empty string")
+		}
+		if err := checkLocationAllowed(station); err != nil {
+			return locationQuery{}, "", false, err
+		}
+		uniqueLocations.addLocation("station " + station)
+		return stationQuery(station), station, true, nil
+	}
+	if raw, ok := args["location_id"]; ok {
+		n, isNumber := raw.(float64)
+		if !isNumber || n != math.Trunc(n) || n < 1 || n > math.MaxInt32 {
+			return locationQuery{}, "", false, invalidArgumentf("location_id must be a positive integer, got %v", raw)
+		}
+		id := strconv.Itoa(int(n))
+		if err := checkLocationAllowed(id); err != nil {
+			return locationQuery{}, "", false, err
+		}
+		uniqueLocations.addLocation("location " + id)
+		return idQuery(int(n)), id, true, nil
+	}
+	return locationQuery{}, "", false, nil
+}
+
+// temperatureFor queries the temperature service for a single location and
+// returns the result, including its formatted line.
+func temperatureFor(ctx context.Context, location string, opts temperatureOptions) (temperatureResult, error) {
+	// Resolve personal aliases such as "home" or "work" to their configured location.
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return temperatureResult{}, err
+	}
+	return temperatureForQuery(ctx, location, query, opts)
+}
+
+// temperatureForQuery is temperatureFor for an already resolved query.
+// location is the argument as given, reported in the structured result.
+func temperatureForQuery(ctx context.Context, location string, query locationQuery, opts temperatureOptions) (temperatureResult, error) {
+	label := query.Label
+
+	// In auto-unit mode, a country named in the location ("Austin, US") decides
+	// the unit up front; otherwise the country reported by the backend does.
+	unit := opts.Unit
+	inferred := false
+	if opts.AutoUnit && query.placeName() != "" {
+		unit, inferred = unitFromLocation(query.placeName())
+	}
+	resp, reading, err := fetchReading(ctx, query, unit, opts)
+	if err != nil {
+		return temperatureResult{}, err
+	}
+	if opts.AutoUnit && !inferred && reading.Country != "" {
+		if local := unitForCountry(reading.Country); local != unit {
+			logf(ctx, "[temperatureFor] Re-querying %q in %s units for country %q", query, local, reading.Country)
+			unit = local
+			if resp, reading, err = fetchReading(ctx, query, unit, opts); err != nil {
+				return temperatureResult{}, err
+			}
+		}
+	}
+
+	result := temperatureResult{Location: location, Unit: unit}
+	// A station or place ID is named after the place it stands for, when the
+	// backend says.
+	if query.Station != "" || query.ID != 0 {
+		result.StationID, result.LocationID = query.Station, query.ID
+		if reading.Location != "" {
+			result.Location = reading.Location
+			label = fmt.Sprintf("%s (%s)", reading.Location, query.Label)
+		}
+	}
+	if opts.Raw {
+		result.Raw = string(resp.Body)
+	}
+	if resp.Stale {
+		result.Stale = true
+		result.CachedAt = &resp.FetchedAt
+	}
+
+	// "No data for this place" is a valid answer, not a backend error.
+	if !reading.hasData() {
+		logf(ctx, "[temperatureFor] No temperature data for %q", query)
+		result.Label = label
+		result.Text = fmt.Sprintf("Temperature for %s: %s", label, cfg.UnavailableMessage) + resp.staleNote()
+		return result, nil
+	}
+	result.Available = true
+	value := opts.Format.round(*reading.Temperature)
+	result.Temperature = &value
+
+	result.ObservedAt = reading.Time
+	if cfg.ReferenceUnit != "" {
+		result.Reference = &referenceReading{Temperature: toReference(*reading.Temperature, unit), Unit: cfg.ReferenceUnit}
+	}
+
+	// Return the temperature result as plain text.
+	if reading.Time != nil {
+		label += " at " + reading.Time.Format(time.RFC3339)
+	}
+	result.Label = label
+	result.Text = fmt.Sprintf("Temperature for %s: %s", label, formatTemperature(value, unit, opts.Format))
+	if opts.IncludeTrend {
+		result.Trend = reading.trend(time.Now())
+		result.Text += fmt.Sprintf(" (trend: %s)", result.Trend)
+	}
+	if opts.IncludeDew {
+		if dew, ok := reading.dewPoint(unit); ok {
+			if result.Reference != nil {
+				refDew := toReference(dew, unit)
+				result.Reference.DewPoint = &refDew
+			}
+			dew = opts.Format.round(dew)
+			result.DewPoint = &dew
+			result.Text += fmt.Sprintf(" (dew point: %s)", formatTemperature(dew, unit, opts.Format))
+		} else {
+			result.Text += " (dew point: unavailable)"
+		}
+	}
+	if opts.IncludeCategory {
+		category, apparent, feelsLike, _ := reading.category(unit)
+		result.Category = category
+		if feelsLike {
+			apparent = opts.Format.round(apparent)
+			result.FeelsLike = &apparent
+			result.Text += fmt.Sprintf(" (feels like %s: %s)", formatTemperature(apparent, unit, opts.Format), category)
+		} else {
+			result.Text += fmt.Sprintf(" (category: %s)", category)
+		}
+	}
+	if opts.IncludeSource {
+		if source := strings.TrimSpace(reading.Source); source != "" {
+			result.Source = source
+			result.Text += fmt.Sprintf(" (via %s)", source)
+		} else {
+			result.Text += " (source: unknown)"
+		}
+	}
+	if opts.IncludeEmoji {
+		result.Emoji = emojiFor(reading.Conditions)
+		result.Text = emojiPrefix(result.Emoji) + result.Text
+	}
+	result.Text += resp.staleNote()
+	return result, nil
+}
+
+// multiUnitResult is the structured result of a get_temperature call that
+// asked for several units.
+type multiUnitResult struct {
+	Location string              `json:"location"`
+	Units    []temperatureResult `json:"units"`
+}
+
+// multiUnitTemperature fetches one location's temperature in each of units
+// with fetch, and lists them under a single heading. Kelvin is converted from
+// the metric reading, so with caching enabled asking for both costs a single
+// backend call.
+func multiUnitTemperature(units []string, opts temperatureOptions, fetch func(temperatureOptions) (temperatureResult, error)) (*mcp.CallToolResult, error) {
+	var result multiUnitResult
+	var b strings.Builder
+	for i, unit := range units {
+		opts.Unit = unit
+		r, err := fetch(opts)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result.Location = r.Location
+			fmt.Fprintf(&b, "%sTemperature for %s:", emojiPrefix(r.Emoji), r.Label)
+		}
+		fmt.Fprintf(&b, "\n- %s", strings.TrimPrefix(r.Text, emojiPrefix(r.Emoji)+"Temperature for "+r.Label+": "))
+		result.Units = append(result.Units, r)
+	}
+	out, err := newStructuredResult(b.String(), result, opts.Pretty)
+	if err != nil {
+		return nil, err
+	}
+	return appendRaw(out, opts.Pretty, result.Units...), nil
+}
+
+// safeTemperatureFor calls temperatureFor, turning a panic into an error.
+// Combined queries run it in their own goroutines, where recoveryMiddleware
+// cannot reach.
+func safeTemperatureFor(ctx context.Context, location string, opts temperatureOptions) (result temperatureResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logf(ctx, "[safeTemperatureFor] PANIC for %q: %v\n%s", location, r, debug.Stack())
+			err = errors.New("internal error")
+		}
+	}()
+	return temperatureFor(ctx, location, opts)
+}
+
+// fetchReading queries the temperature service for location in unit and
+// returns the backend response along with its parsed reading.
+func fetchReading(ctx context.Context, location locationQuery, unit string, opts temperatureOptions) (backendResponse, temperatureReading, error) {
+	params := url.Values{}
+	location.setParams(params)
+	params.Set("units", backendUnit(unit))
+	if !opts.At.IsZero() {
+		params.Set("time", opts.At.UTC().Format(time.RFC3339))
+	}
+	setLangParam(params, opts.Lang)
+	resp, err := fetchBackend(ctx, "/temperature", params)
+	if err != nil {
+		return backendResponse{}, temperatureReading{}, err
+	}
+	// A NaN or Infinity temperature means no data, like null.
+	body, replaced := nullNonFinite(resp.Body)
+	if replaced {
+		logf(ctx, "[fetchReading] Backend reported NaN or Infinity for %q, treating it as no data", location)
+	}
+	if cfg.StrictResponse {
+		if err := validateResponse(body, temperatureRules()); err != nil {
+			logf(ctx, "[fetchReading] ERROR: %v", err)
+			return backendResponse{}, temperatureReading{}, err
+		}
+	}
+	reading, err := parseReading(body)
+	if err != nil {
+		return backendResponse{}, temperatureReading{}, err
+	}
+	// The backend has no Kelvin mode: it was asked for Celsius, convert locally.
+	if unit == "kelvin" {
+		reading.toKelvin()
+	}
+	return resp, reading, nil
+}
+
+// parseReading decodes a backend temperature response.
+func parseReading(body []byte) (temperatureReading, error) {
+	var reading temperatureReading
+	if err := json.Unmarshal(body, &reading); err != nil {
+		return temperatureReading{}, invalidResponsef("failed to parse temperature response: %w", err)
+	}
+	if cfg.TemperatureField != defaultTemperatureField {
+		var err error
+		if reading.Temperature, err = mappedTemperature(body); err != nil {
+			return temperatureReading{}, err
+		}
+	}
+	return reading, nil
+}
+
+// failedResult describes a location of a combined query whose fetch failed.
+func failedResult(location string, err error) temperatureResult {
+	reason := failureReason(err)
+	return temperatureResult{
+		Location: location,
+		Error:    reason,
+		Text:     fmt.Sprintf("Temperature for %s: unavailable (%s)", location, reason),
+	}
+}
+
+// combinedResult assembles the output of a combined query. Locations that
+// succeeded are listed with their temperature; the ones that failed are
+// marked as unavailable and summarized with the reason. The call only fails
+// when no location could be fetched at all. pretty indents the JSON.
+func combinedResult(results []temperatureResult, errs []error, pretty bool) (*mcp.CallToolResult, error) {
+	var lines, missing []string
+	for i, result := range results {
+		lines = append(lines, result.Text)
+		if errs[i] != nil {
+			missing = append(missing, fmt.Sprintf("%s (%s)", result.Location, result.Error))
+		}
+	}
+	if len(missing) == len(results) {
+		return nil, withClass(sharedClass(errs), fmt.Errorf("no temperature could be fetched: %s", strings.Join(missing, "; ")))
+	}
+	if len(missing) > 0 {
+		lines = append(lines, fmt.Sprintf("Missing results for %d of %d locations: %s",
+			len(missing), len(results), strings.Join(missing, "; ")))
+	}
+	out, err := newStructuredResult(strings.Join(lines, "\n"), map[string]any{"results": results}, pretty)
+	if err != nil {
+		return nil, err
+	}
+	return appendRaw(out, pretty, results...), nil
+}
+
+// appendRaw adds the raw backend body of each result that kept one as an
+// extra content block, after the formatted and structured blocks.
+func appendRaw(out *mcp.CallToolResult, pretty bool, results ...temperatureResult) *mcp.CallToolResult {
+	for _, result := range results {
+		if result.Raw != "" {
+			out.Content = append(out.Content, mcp.NewTextContent(indentRawJSON(result.Raw, pretty)))
+		}
+	}
+	return out
+}
+
+// failureReason summarizes err for a per-location failure note.
+func failureReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "backend timed out"
+	}
+	return err.Error()
+}
@@ -0,0 +1,109 @@
+// temperature_test.go
+// Tests of the get_temperature tool.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// panickingTransport panics on every request, standing in for a bug
+// anywhere below the handler.
+type panickingTransport struct{}
+
+func (panickingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	panic("boom")
+}
+
+// TestCombinedQueryPanic checks that a panic while fetching one location of
+// a combined query, which runs in its own goroutine out of
+// recoveryMiddleware's reach, fails that location instead of the server.
+func TestCombinedQueryPanic(t *testing.T) {
+	setupConfig(t, nil)
+	httpClient = &http.Client{Transport: panickingTransport{}}
+
+	_, err := temperatureHandler(context.Background(), toolRequest(map[string]any{"locations": []any{"Lisbon", "Porto"}}))
+	if err == nil {
+		t.Fatal("temperatureHandler succeeded with every location panicking")
+	}
+	for _, want := range []string{"Lisbon (internal error)", "Porto (internal error)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+// TestCombinedQueryConcurrency checks that a combined query fetches at most
+// BATCH_CONCURRENCY locations at once.
+func TestCombinedQueryConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	setupBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, `{"temperature":20}`)
+	}), map[string]string{"BATCH_CONCURRENCY": "2", "CACHE_TTL": "0"})
+
+	locations := []any{"Lisbon", "Porto", "Faro", "Braga", "Coimbra", "Evora"}
+	result, err := temperatureHandler(context.Background(), toolRequest(map[string]any{"locations": locations}))
+	if err != nil {
+		t.Fatalf("temperatureHandler: %v", err)
+	}
+	if text := resultText(t, result); strings.Count(text, "20°C") != len(locations) {
+		t.Errorf("text = %q, want a temperature for each of the %d locations", text, len(locations))
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d backend requests in flight at once, want at most BATCH_CONCURRENCY 2", p)
+	}
+}
+
+func TestRequestedLocationsLimit(t *testing.T) {
+	list := make([]any, maxBatchItems+1)
+	for i := range list {
+		list[i] = fmt.Sprintf("Location %d", i)
+	}
+	if _, err := requestedLocations(map[string]any{"locations": list}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("requestedLocations with %d locations: error %v, want an invalid argument", len(list), err)
+	}
+	locations, err := requestedLocations(map[string]any{"locations": list[:maxBatchItems]})
+	if err != nil || len(locations) != maxBatchItems {
+		t.Errorf("requestedLocations with %d locations = %d locations, %v", maxBatchItems, len(locations), err)
+	}
+}
+
+// TestCombinedResultClass checks the class of a combined query where every
+// location failed: the one all the failures share, or a backend outage.
+func TestCombinedResultClass(t *testing.T) {
+	notFound := withClass(ErrNotFound, errors.New("404"))
+	tests := []struct {
+		name string
+		errs []error
+		want error
+	}{
+		{"shared class", []error{notFound, notFound}, ErrNotFound},
+		{"mixed classes", []error{notFound, withClass(ErrRateLimited, errors.New("429"))}, ErrBackendUnavailable},
+		{"unclassified", []error{errors.New("internal error"), notFound}, ErrBackendUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]temperatureResult, len(tt.errs))
+			for i, err := range tt.errs {
+				results[i] = failedResult(fmt.Sprintf("Location %d", i), err)
+			}
+			_, err := combinedResult(results, tt.errs, false)
+			if classOf(err) != tt.want {
+				t.Errorf("combinedResult error %v has class %v, want %v", err, classOf(err), tt.want)
+			}
+		})
+	}
+}
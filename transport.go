@@ -0,0 +1,100 @@
+// transport.go
+// The stdio and SSE transports.
+//
+// Both are the stock mcp-go transports with the incoming messages passed
+// through interceptSetLevel first, so logging/setLevel works on either. SSE
+// shuts down gracefully on SIGTERM or SIGINT (see shutdown.go).
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// serveStdio serves s over standard input and output until stdin is closed
+// or the process is interrupted. stdout carries the protocol, so the stdio
+// server's errors go to the log, and os.Stdout is pointed at stderr for the
+// rest of the process: a stray print anywhere, including in a dependency,
+// can then never corrupt the MCP stream.
+func serveStdio(s *server.MCPServer) error {
+	stdio := server.NewStdioServer(s)
+	stdio.SetErrorLogger(log.Default())
+
+	protocol := os.Stdout
+	os.Stdout = os.Stderr
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	return stdio.Listen(ctx, &setLevelReader{r: bufio.NewReader(os.Stdin)}, protocol)
+}
+
+// setLevelReader passes stdio input through interceptSetLevel a line at a time.
+type setLevelReader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+func (r *setLevelReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		line, err := r.r.ReadBytes('\n')
+		if len(line) == 0 {
+			return 0, err
+		}
+		trimmed := bytes.TrimRight(line, "\r\n")
+		r.pending = append(interceptSetLevel("stdio", trimmed), line[len(trimmed):]...)
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// serveSSE serves s over HTTP with server-sent events on addr until the
+// server fails or the process is interrupted, then shuts down gracefully.
+func serveSSE(s *server.MCPServer, addr string) error {
+	srv := &http.Server{Addr: addr}
+	sse := server.NewSSEServer(s, server.WithHTTPServer(srv), server.WithSSEContextFunc(withClientIP))
+	srv.Handler = setLevelHandler(sse)
+	if cfg.SSECompression {
+		srv.Handler = gzipHandler(srv.Handler)
+	}
+
+	// srv is run directly rather than through sse.Start, which holds a lock
+	// for as long as it serves and so keeps sse.Shutdown from ever running.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownSSE(srv, cfg.ShutdownGrace)
+		return nil
+	}
+}
+
+// setLevelHandler passes messages posted to the SSE message endpoint through
+// interceptSetLevel before next handles them.
+func setLevelHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sessionID := r.URL.Query().Get("sessionId"); r.Method == http.MethodPost && sessionID != "" {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(interceptSetLevel(sessionID, body)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,62 @@
+// trend.go
+// Temperature trend detection.
+//
+// The trend tells agents which way the temperature is heading. It comes from
+// the backend's own "trend" field when present, and is otherwise derived from
+// the recent samples the backend returns.
+
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// trendWindow is how far back samples are considered when computing a trend.
+const trendWindow = time.Hour
+
+// trendThreshold is the smallest change, in degrees, that counts as rising or
+// falling rather than steady.
+const trendThreshold = 0.5
+
+// temperatureSample is a single timestamped reading.
+type temperatureSample struct {
+	Time time.Time `json:"time"`
+	// Temperature is nil for a sample without data, which is skipped.
+	Temperature *float64 `json:"temperature"`
+}
+
+// trend returns "rising", "falling", "steady", or "unknown" when the reading
+// carries neither a trend field nor enough recent samples.
+func (r temperatureReading) trend(now time.Time) string {
+	switch t := strings.ToLower(strings.TrimSpace(r.Trend)); t {
+	case "rising", "falling", "steady":
+		return t
+	}
+
+	// Compare the oldest and newest samples within the trend window.
+	var first, last *temperatureSample
+	for i := range r.Recent {
+		sample := &r.Recent[i]
+		if sample.Time.IsZero() || sample.Temperature == nil || now.Sub(sample.Time) > trendWindow {
+			continue
+		}
+		if first == nil || sample.Time.Before(first.Time) {
+			first = sample
+		}
+		if last == nil || sample.Time.After(last.Time) {
+			last = sample
+		}
+	}
+	if first == nil || first == last {
+		return "unknown"
+	}
+	switch delta := *last.Temperature - *first.Temperature; {
+	case delta >= trendThreshold:
+		return "rising"
+	case delta <= -trendThreshold:
+		return "falling"
+	default:
+		return "steady"
+	}
+}
@@ -0,0 +1,121 @@
+// truncate.go
+// The output length limit.
+//
+// Some clients choke on very long tool results, such as large combined
+// queries or long forecasts. With MAX_OUTPUT_CHARS set, a result whose text
+// adds up to more characters than that is cut down: the human-readable text
+// is truncated at the last line break (or, failing that, word break) that
+// fits, so no value is cut in half or split from its unit, and JSON blocks
+// that no longer fit are left out rather than broken. A note at the end of the text says so.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// minOutputLimit is the smallest accepted MAX_OUTPUT_CHARS, which leaves
+// room for the truncation note and some output.
+const minOutputLimit = 200
+
+// truncationNoteReserve is the number of characters kept free for the note
+// appended to truncated output.
+const truncationNoteReserve = 100
+
+// limitOutput cuts the text of result down to limit characters.
+func limitOutput(result *mcp.CallToolResult, limit int) *mcp.CallToolResult {
+	if result == nil || len(result.Content) == 0 {
+		return result
+	}
+	total := 0
+	for _, c := range result.Content {
+		if text, ok := c.(mcp.TextContent); ok {
+			total += utf8.RuneCountInString(text.Text)
+		}
+	}
+	first, ok := result.Content[0].(mcp.TextContent)
+	if total <= limit || !ok {
+		return result
+	}
+
+	budget := limit - truncationNoteReserve
+	var notes []string
+	if utf8.RuneCountInString(first.Text) > budget {
+		first.Text = cutAtBoundary(first.Text, budget)
+		notes = append(notes, "text truncated")
+	}
+	budget -= utf8.RuneCountInString(first.Text)
+
+	// JSON cannot be cut without breaking it, so each block is kept whole
+	// while it fits, and left out otherwise.
+	content := []mcp.Content{first}
+	omitted := 0
+	for _, c := range result.Content[1:] {
+		if text, ok := c.(mcp.TextContent); ok {
+			n := utf8.RuneCountInString(text.Text)
+			if n > budget {
+				omitted++
+				continue
+			}
+			budget -= n
+		}
+		content = append(content, c)
+	}
+	if omitted > 0 {
+		notes = append(notes, fmt.Sprintf("%d JSON block(s) omitted", omitted))
+	}
+	first.Text += fmt.Sprintf("\n[output limited to %d characters: %s]", limit, strings.Join(notes, ", "))
+	content[0] = first
+	return &mcp.CallToolResult{Result: result.Result, Content: content, IsError: result.IsError}
+}
+
+// cutAtBoundary returns the longest prefix of s of at most n characters that
+// ends at a line break or, when no line break is close enough, a space that
+// does not separate a number from what follows it.
+func cutAtBoundary(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	prefix := s
+	for i := range s {
+		if n == 0 {
+			prefix = s[:i]
+			break
+		}
+		n--
+	}
+	// Prefer whole lines, unless that would throw away most of the text.
+	if i := strings.LastIndexByte(prefix, '\n'); i > len(prefix)/2 {
+		return prefix[:i]
+	}
+	if i := strings.LastIndexByte(prefix, ' '); i > 0 {
+		// A number and its unit, as in "18 km/h", go together: never keep
+		// the number without the unit after it.
+		for j := strings.LastIndexByte(prefix[:i], ' '); j > 0 && isNumber(prefix[j+1:i]); j = strings.LastIndexByte(prefix[:i], ' ') {
+			i = j
+		}
+		return strings.TrimRight(prefix[:i], " ,;:")
+	}
+	return prefix
+}
+
+// isNumber reports whether word is a bare number, such as "18", "-3" or
+// "12.5", with either decimal separator.
+func isNumber(word string) bool {
+	digits := false
+	for _, r := range word {
+		switch {
+		case unicode.IsDigit(r):
+			digits = true
+		case strings.ContainsRune("+-.,", r) || string(r) == cfg.DecimalSeparator:
+		default:
+			return false
+		}
+	}
+	return digits
+}
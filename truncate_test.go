@@ -0,0 +1,55 @@
+// truncate_test.go
+// Tests of the output length limit.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCutAtBoundary(t *testing.T) {
+	setupConfig(t, nil)
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"Wind for Lisbon: 18 km/h from the N", 20, "Wind for Lisbon"},
+		{"Wind for Lisbon: 18 km/h from the N", 25, "Wind for Lisbon: 18 km/h"},
+		{"Rain: 12.5 mm, 80 %", 12, "Rain"},
+		{"Pressure: -3 1015 hPa", 18, "Pressure"},
+		{"Temperature for Lisbon: 18°C, Porto: 15°C", 31, "Temperature for Lisbon: 18°C"},
+		{"first line\nsecond line is long", 18, "first line"},
+		{"18 km/h", 4, "18"},
+		{"unbroken", 4, "unbr"},
+	}
+	for _, tt := range tests {
+		if got := cutAtBoundary(tt.s, tt.n); got != tt.want {
+			t.Errorf("cutAtBoundary(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLimitOutput(t *testing.T) {
+	setupConfig(t, nil)
+	text := strings.Repeat("Wind for Lisbon: 18 km/h\n", 20)
+	result := &mcp.CallToolResult{Content: []mcp.Content{
+		mcp.NewTextContent(text),
+		mcp.NewTextContent(`{"speed":` + strings.Repeat("1", 300) + `}`),
+	}}
+	limited := limitOutput(result, 300)
+	if len(limited.Content) != 1 {
+		t.Errorf("got %d content blocks, want the JSON one omitted", len(limited.Content))
+	}
+	got := resultText(t, limited)
+	if !strings.HasSuffix(got, "[output limited to 300 characters: text truncated, 1 JSON block(s) omitted]") {
+		t.Errorf("text does not end with the truncation note: %q", got)
+	}
+	if n := len([]rune(got)); n > 300 {
+		t.Errorf("text is %d characters long, over the limit of 300", n)
+	}
+}
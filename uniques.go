@@ -0,0 +1,88 @@
+// uniques.go
+// Count of the distinct locations queried.
+//
+// For cache tuning it helps to know how diverse the queried locations are.
+// Every location resolved for a tool call is added to a HyperLogLog sketch,
+// which estimates the number of distinct ones within about 2% in a fixed
+// 4 KiB, however many are seen. Locations are compared case-insensitively,
+// aliases by the location they stand for. server_info reports the estimate.
+// TRACK_UNIQUE_LOCATIONS=false turns the counter off.
+
+package main
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"strings"
+	"sync"
+)
+
+// uniqueLocationsPrecision is the number of hash bits that pick a register;
+// 2^12 registers give a standard error of about 1.6%.
+const uniqueLocationsPrecision = 12
+
+// uniqueLocations counts the distinct locations queried; nil when disabled.
+var uniqueLocations *hyperLogLog
+
+// hyperLogLog is a concurrency-safe HyperLogLog cardinality estimator. A nil
+// *hyperLogLog ignores additions.
+type hyperLogLog struct {
+	seed maphash.Seed
+
+	mu        sync.Mutex
+	registers [1 << uniqueLocationsPrecision]uint8
+}
+
+// newUniqueLocations returns the counter selected by c, or nil when disabled.
+func newUniqueLocations(c *config) *hyperLogLog {
+	if !c.TrackUniqueLocations {
+		return nil
+	}
+	return &hyperLogLog{seed: maphash.MakeSeed()}
+}
+
+// addLocation records a queried location.
+func (h *hyperLogLog) addLocation(location string) {
+	if h == nil {
+		return
+	}
+	x := maphash.String(h.seed, strings.ToLower(strings.TrimSpace(location)))
+	index := x >> (64 - uniqueLocationsPrecision)
+	// The rank is the position of the first set bit in what is left.
+	rank := uint8(bits.LeadingZeros64(x<<uniqueLocationsPrecision|1<<(uniqueLocationsPrecision-1)) + 1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// estimate returns the estimated number of distinct locations added.
+func (h *hyperLogLog) estimate() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// Small cardinalities are estimated more accurately by linear counting.
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(e))
+}
+
+// status describes the counter for server_info.
+func (h *hyperLogLog) status() string {
+	if h == nil {
+		return "disabled"
+	}
+	return fmt.Sprintf("about %d", h.estimate())
+}
@@ -0,0 +1,31 @@
+// uniques_test.go
+// Tests of the distinct location counter.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHyperLogLogEstimate(t *testing.T) {
+	// 1.04/√m is the standard error of the estimate; allow four of them so the
+	// randomly seeded hash fails the test only once in many thousand runs.
+	bound := 4 * 1.04 / math.Sqrt(1<<uniqueLocationsPrecision)
+	setupConfig(t, map[string]string{"TRACK_UNIQUE_LOCATIONS": "true"})
+	for _, n := range []int{10, 100, 1000, 10000, 100000} {
+		h := newUniqueLocations(cfg)
+		for i := 0; i < n; i++ {
+			location := fmt.Sprintf("Location %d", i)
+			// Repeats, in any case, count once.
+			h.addLocation(location)
+			h.addLocation(" " + strings.ToUpper(location))
+		}
+		got := h.estimate()
+		if e := math.Abs(float64(got)-float64(n)) / float64(n); e > bound {
+			t.Errorf("estimate for %d distinct locations = %d, off by %.1f%%, want within %.1f%%", n, got, 100*e, 100*bound)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// units.go
+// Unit handling.
+//
+// The backend understands two unit systems, "metric" and "imperial". Client
+// input is normalized to one of them, either from an explicit unit or, in
+// auto-unit mode, from the local convention of the location's country. An
+// unrecognized unit means metric, or an error with STRICT_UNITS set.
+// UNIT_SYNONYMS adds names of its own to the built-in ones, e.g. for common
+// misspellings or regional names.
+// Kelvin is not a backend unit: depending on UNSUPPORTED_UNIT_POLICY it is
+// either converted locally from metric or rejected with a clear error.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// validUnits lists the accepted unit names, for error messages.
+const validUnits = "metric (celsius, c), imperial (fahrenheit, f) or kelvin (k, standard)"
+
+// normalizeUnit maps a requested unit to 'metric', 'imperial' or 'kelvin'
+// with the configured UNIT_SYNONYMS and STRICT_UNITS (see normalizeUnitWith),
+// logging the units it falls back to metric for.
+func normalizeUnit(unit string) (string, error) {
+	normalized, fallback, err := normalizeUnitWith(unit, cfg.UnitSynonyms, cfg.StrictUnits)
+	if fallback {
+		log.Printf("[normalizeUnit] WARNING: unknown unit %q, using metric", unit)
+	}
+	return normalized, err
+}
+
+// normalizeUnitWith maps a requested unit to 'metric', 'imperial' or
+// 'kelvin', through the built-in names and synonyms, defaulting to 'metric'
+// when none is given. An unrecognized unit also falls back to 'metric',
+// reported by fallback, unless strict is set, in which case it is an error.
+// It depends on nothing but its arguments.
+func normalizeUnitWith(unit string, synonyms map[string]string, strict bool) (normalized string, fallback bool, err error) {
+	if u, ok := resolveUnit(unit, synonyms); ok {
+		return u, false, nil
+	}
+	if strings.TrimSpace(unit) == "" {
+		return "metric", false, nil
+	}
+	if strict {
+		return "", false, invalidArgumentf("unknown unit %q: must be %s", unit, validUnits)
+	}
+	return "metric", true, nil
+}
+
+// unitsArg reads the "unit" argument, which is either a single unit or a
+// list of them. A single unit follows normalizeUnit; every entry of a list
+// must be recognized, whatever STRICT_UNITS says, and duplicates (also under
+// different names, such as "c" and "celsius") are dropped. given reports
+// whether the argument was present at all.
+func unitsArg(args map[string]any) (units []string, given bool, err error) {
+	switch v := args["unit"].(type) {
+	case nil:
+		return []string{"metric"}, false, nil
+	case string:
+		unit, err := normalizeUnit(v)
+		if err != nil {
+			return nil, false, err
+		}
+		return []string{unit}, strings.TrimSpace(v) != "", nil
+	case []any:
+		seen := make(map[string]bool)
+		for _, item := range v {
+			name, _ := item.(string)
+			unit, ok := parseUnit(name)
+			if !ok {
+				return nil, false, invalidArgumentf("unknown unit %q in unit list: must be %s", fmt.Sprint(item), validUnits)
+			}
+			if !seen[unit] {
+				seen[unit] = true
+				units = append(units, unit)
+			}
+		}
+		if len(units) == 0 {
+			return nil, false, invalidArgumentf("unit list must not be empty")
+		}
+		return units, true, nil
+	default:
+		return nil, false, invalidArgumentf("unit must be a string or a list of strings")
+	}
+}
+
+// stringOrList makes a WithString property accept a list of strings too.
+func stringOrList() mcp.PropertyOption {
+	return func(schema map[string]any) {
+		delete(schema, "type")
+		schema["anyOf"] = []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		}
+	}
+}
+
+// parseUnit maps a unit name or symbol, built in or from UNIT_SYNONYMS, to
+// 'metric', 'imperial' or 'kelvin'. ok is false for anything it does not
+// recognize.
+func parseUnit(unit string) (string, bool) {
+	return resolveUnit(unit, cfg.UnitSynonyms)
+}
+
+// resolveUnit is parseUnit with the extra synonyms given explicitly, which
+// map lower-case names to normalized units. It depends on nothing else, so
+// the configuration can use it while it is being loaded.
+func resolveUnit(unit string, synonyms map[string]string) (string, bool) {
+	name := strings.ToLower(strings.TrimSpace(unit))
+	switch name {
+	case "celsius", "c", "metric":
+		return "metric", true
+	case "fahrenheit", "f", "imperial":
+		return "imperial", true
+	case "kelvin", "k", "standard":
+		return "kelvin", true
+	default:
+		u, ok := synonyms[name]
+		return u, ok
+	}
+}
+
+// parseUnitSynonyms parses a semicolon-separated list of name=unit pairs,
+// e.g. "centigrade=metric;fahrenheight=imperial". The unit may be given by
+// any built-in name; the synonym itself must not be one.
+func parseUnitSynonyms(raw string) (map[string]string, error) {
+	synonyms := make(map[string]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("entry %q must be of the form name=unit", entry)
+		}
+		if _, builtin := resolveUnit(name, nil); builtin {
+			return nil, fmt.Errorf("entry %q: %q is already a built-in unit name", entry, name)
+		}
+		unit, ok := resolveUnit(target, nil)
+		if !ok {
+			return nil, fmt.Errorf("entry %q: unit must be %s", entry, validUnits)
+		}
+		synonyms[name] = unit
+	}
+	return synonyms, nil
+}
+
+// checkUnitSupported rejects units the backend cannot serve when the
+// unsupported-unit policy is "error".
+func checkUnitSupported(unit string) error {
+	if unit == "kelvin" && cfg.UnsupportedUnitPolicy == "error" {
+		return invalidArgumentf("unit %q is not supported by the temperature service (supported: metric, imperial)", unit)
+	}
+	return nil
+}
+
+// backendUnit returns the units= token to request from the backend for unit,
+// translated through BACKEND_UNIT_MAP when it names one. Kelvin values are
+// converted locally from metric.
+func backendUnit(unit string) string {
+	if unit == "kelvin" {
+		unit = "metric"
+	}
+	if token, ok := cfg.UnitTokens[unit]; ok {
+		return token
+	}
+	return unit
+}
+
+// celsiusToKelvin converts a Celsius temperature to Kelvin.
+func celsiusToKelvin(c float64) float64 {
+	return c + 273.15
+}
+
+// toCelsius converts value, expressed in unit, to Celsius.
+func toCelsius(value float64, unit string) float64 {
+	switch unit {
+	case "imperial":
+		return (value - 32) * 5 / 9
+	case "kelvin":
+		return value - 273.15
+	default:
+		return value
+	}
+}
+
+// fromCelsius converts a Celsius temperature to unit.
+func fromCelsius(c float64, unit string) float64 {
+	switch unit {
+	case "imperial":
+		return c*9/5 + 32
+	case "kelvin":
+		return celsiusToKelvin(c)
+	default:
+		return c
+	}
+}
+
+// imperialCountries lists, by ISO code and common name, the countries that
+// report temperatures in Fahrenheit.
+var imperialCountries = map[string]bool{
+	"us": true, "usa": true, "united states": true, "united states of america": true,
+	"lr": true, "liberia": true,
+	"mm": true, "myanmar": true,
+}
+
+// unitForCountry returns the unit system customarily used in country.
+func unitForCountry(country string) string {
+	if imperialCountries[strings.ToLower(strings.TrimSpace(country))] {
+		return "imperial"
+	}
+	return "metric"
+}
+
+// unitFromLocation infers the unit from a country named as the last
+// comma-separated part of location (e.g. "Austin, TX, US"). It reports false,
+// and the default 'metric', when no known country is named.
+func unitFromLocation(location string) (string, bool) {
+	i := strings.LastIndex(location, ",")
+	if i < 0 {
+		return "metric", false
+	}
+	country := strings.ToLower(strings.TrimSpace(location[i+1:]))
+	if imperialCountries[country] {
+		return "imperial", true
+	}
+	if knownMetricCountries[country] {
+		return "metric", true
+	}
+	return "metric", false
+}
+
+// knownMetricCountries lists common country names, so "Lisbon, Portugal" is
+// recognized as a metric location without asking the backend. Two-letter
+// codes are deliberately left out: "Austin, TX" names a state, not Tuvalu.
+var knownMetricCountries = map[string]bool{
+	"canada": true, "mexico": true, "united kingdom": true, "uk": true,
+	"france": true, "germany": true, "spain": true, "portugal": true,
+	"italy": true, "brazil": true, "japan": true, "china": true,
+	"india": true, "australia": true,
+}
+
+// unitSymbol returns the temperature symbol for a unit system.
+func unitSymbol(unit string) string {
+	switch unit {
+	case "imperial":
+		return "°F"
+	case "kelvin":
+		return " K"
+	default:
+		return "°C"
+	}
+}
+
+// formatTemperature renders value with the symbol of unit, e.g. "18.25°C".
+func formatTemperature(value float64, unit string, f numberFormat) string {
+	return f.format(value) + unitSymbol(unit)
+}
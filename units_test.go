@@ -0,0 +1,78 @@
+// units_test.go
+// Tests of the unit normalization.
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeUnitWith(t *testing.T) {
+	synonyms := map[string]string{"centigrade": "metric", "fahrenheight": "imperial"}
+	tests := []struct {
+		unit         string
+		strict       bool
+		want         string
+		wantFallback bool
+		wantErr      bool
+	}{
+		{"", false, "metric", false, false},
+		{"  ", true, "metric", false, false},
+		{"celsius", false, "metric", false, false},
+		{" F ", false, "imperial", false, false},
+		{"Kelvin", true, "kelvin", false, false},
+		{"standard", false, "kelvin", false, false},
+		{"centigrade", true, "metric", false, false},
+		{"FAHRENHEIGHT", true, "imperial", false, false},
+		{"rankine", false, "metric", true, false},
+		{"rankine", true, "", false, true},
+	}
+	for _, tt := range tests {
+		got, fallback, err := normalizeUnitWith(tt.unit, synonyms, tt.strict)
+		if got != tt.want || fallback != tt.wantFallback || (err != nil) != tt.wantErr {
+			t.Errorf("normalizeUnitWith(%q, strict %t) = %q, %t, %v; want %q, %t, error %t",
+				tt.unit, tt.strict, got, fallback, err, tt.want, tt.wantFallback, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("normalizeUnitWith(%q) error %v is not an invalid argument", tt.unit, err)
+		}
+	}
+}
+
+func TestParseUnitSynonyms(t *testing.T) {
+	synonyms, err := parseUnitSynonyms(" CentiGrade = Celsius ; fahrenheight=IMPERIAL;;absolute=k ")
+	if err != nil {
+		t.Fatalf("parseUnitSynonyms: %v", err)
+	}
+	// The synonyms are added to the built-in names, which keep working.
+	for unit, want := range map[string]string{
+		"centigrade":   "metric",
+		"CENTIGRADE":   "metric",
+		"Fahrenheight": "imperial",
+		"absolute":     "kelvin",
+		"celsius":      "metric",
+		"f":            "imperial",
+		"k":            "kelvin",
+	} {
+		if got, ok := resolveUnit(unit, synonyms); !ok || got != want {
+			t.Errorf("resolveUnit(%q) = %q, %t; want %q", unit, got, ok, want)
+		}
+	}
+	if _, ok := resolveUnit("rankine", synonyms); ok {
+		t.Error("resolveUnit recognized rankine")
+	}
+
+	for _, raw := range []string{
+		"centigrade",         // no unit
+		"=metric",            // no name
+		"centigrade=rankine", // not a unit
+		"centigrade=",        // empty unit
+		"Celsius=imperial",   // redefines a built-in name
+		"ok=metric;bad",      // one bad entry fails them all
+	} {
+		if got, err := parseUnitSynonyms(raw); err == nil {
+			t.Errorf("parseUnitSynonyms(%q) = %v, want an error", raw, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// uv.go
+// The "get_uv_index" tool.
+//
+// get_uv_index returns the current UV index for a location, queried from the
+// backend's /uv endpoint, together with its WHO risk category.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newUVIndexTool defines the "get_uv_index" tool.
+func newUVIndexTool() mcp.Tool {
+	return mcp.NewTool("get_uv_index",
+		readOnlyAnnotation("UV Index", true),
+		mcp.WithDescription("Get the current UV index and its risk category (Low to Extreme) for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the UV index for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		formatOptions("the value as reported"),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// uvReading is the backend's /uv response.
+type uvReading struct {
+	Location string   `json:"location"`
+	UVIndex  *float64 `json:"uv_index"`
+}
+
+// uvResult is the structured result of get_uv_index.
+type uvResult struct {
+	Location  string   `json:"location"`
+	Available bool     `json:"available"`
+	UVIndex   *float64 `json:"uv_index,omitempty"`
+	Category  string   `json:"category,omitempty"`
+	Stale     bool     `json:"stale,omitempty"`
+}
+
+// uvCategory returns the WHO exposure category for a UV index. The index is
+// published as a whole number, so the value is rounded before it is classed.
+func uvCategory(index float64) string {
+	switch i := math.Round(index); {
+	case i <= 2:
+		return "Low"
+	case i <= 5:
+		return "Moderate"
+	case i <= 7:
+		return "High"
+	case i <= 10:
+		return "Very High"
+	default:
+		return "Extreme"
+	}
+}
+
+// uvIndexHandler handles incoming requests to the "get_uv_index" tool.
+func uvIndexHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[uvIndexHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var reading uvReading
+	backend, err := fetchMetricReading(ctx, query, "/uv", nil, "UV index", &reading)
+	if err != nil {
+		return nil, err
+	}
+
+	result := uvResult{Location: location, Stale: backend.Stale}
+	if reading.UVIndex == nil {
+		text := fmt.Sprintf("UV index for %s: no UV data is available for this location", query.Label)
+		return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+	}
+	index := format.round(*reading.UVIndex)
+	result.Available = true
+	result.UVIndex = &index
+	result.Category = uvCategory(*reading.UVIndex)
+	text := fmt.Sprintf("UV index for %s: %s (%s)", query.Label, format.format(index), result.Category)
+	return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+}
@@ -0,0 +1,93 @@
+// validate.go
+// Strict validation of backend responses.
+//
+// With STRICT_RESPONSE enabled, every temperature response is checked against
+// the shape the server expects before it is used, so changes to the backend
+// contract fail loudly instead of producing odd output.
+
+package main
+
+import (
+	"encoding/json"
+)
+
+// fieldRule describes one expected field of a backend response.
+type fieldRule struct {
+	Name     string
+	Type     string // "string", "number" or "array"
+	Required bool
+	// Nullable allows an explicit null, e.g. a temperature for a place without data.
+	Nullable bool
+}
+
+// temperatureSchema lists the fields expected in a temperature response.
+var temperatureSchema = []fieldRule{
+	{Name: "location", Type: "string", Required: true},
+	{Name: "temperature", Type: "number", Required: true, Nullable: true},
+	{Name: "trend", Type: "string"},
+	{Name: "conditions", Type: "string"},
+	{Name: "source", Type: "string"},
+	{Name: "humidity", Type: "number", Nullable: true},
+	{Name: "dew_point", Type: "number", Nullable: true},
+	{Name: "feels_like", Type: "number", Nullable: true},
+	{Name: "time", Type: "string"},
+	{Name: "recent", Type: "array"},
+}
+
+// temperatureRules returns temperatureSchema, without its "temperature"
+// rule when TEMPERATURE_FIELD moves the temperature elsewhere; the mapped
+// field is then checked by mappedTemperature.
+func temperatureRules() []fieldRule {
+	if cfg.TemperatureField == defaultTemperatureField {
+		return temperatureSchema
+	}
+	var rules []fieldRule
+	for _, rule := range temperatureSchema {
+		if rule.Name != "temperature" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// validateResponse checks that body is a JSON object matching rules and
+// returns an error naming the first missing or invalid field.
+func validateResponse(body []byte, rules []fieldRule) error {
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return invalidResponsef("backend response is not a JSON object: %w", err)
+	}
+	for _, rule := range rules {
+		v, ok := obj[rule.Name]
+		if ok && v == nil && rule.Nullable {
+			continue
+		}
+		if !ok || v == nil {
+			if rule.Required {
+				return invalidResponsef("backend response is missing required field %q", rule.Name)
+			}
+			continue
+		}
+		if !hasJSONType(v, rule.Type) {
+			return invalidResponsef("backend response field %q must be a %s, got %T", rule.Name, rule.Type, v)
+		}
+	}
+	return nil
+}
+
+// hasJSONType reports whether a decoded JSON value v is of the named type.
+func hasJSONType(v any, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	default:
+		return false
+	}
+}
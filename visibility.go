@@ -0,0 +1,111 @@
+// visibility.go
+// The "get_visibility" tool.
+//
+// get_visibility returns the horizontal visibility distance for a location,
+// queried from the backend's /visibility endpoint. The backend reports
+// kilometres; imperial requests are converted locally to statute miles.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// kmPerMile is the number of kilometres in one statute mile.
+const kmPerMile = 1.609344
+
+// milesPrecision is the default number of decimals for converted mile values,
+// which otherwise carry meaningless digits from the conversion.
+const milesPrecision = 1
+
+// newVisibilityTool defines the "get_visibility" tool.
+func newVisibilityTool() mcp.Tool {
+	return mcp.NewTool("get_visibility",
+		readOnlyAnnotation("Visibility", true),
+		mcp.WithDescription("Get the current visibility distance for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the visibility for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system: metric for kilometres (the default) or imperial for miles"),
+		),
+		formatOptions("the value as reported for km and 1 for miles"),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// visibilityReading is the backend's /visibility response.
+type visibilityReading struct {
+	Location string `json:"location"`
+	// Visibility is in kilometres.
+	Visibility *float64 `json:"visibility"`
+}
+
+// visibilityResult is the structured result of get_visibility.
+type visibilityResult struct {
+	Location   string   `json:"location"`
+	Available  bool     `json:"available"`
+	Visibility *float64 `json:"visibility,omitempty"`
+	// Unit is "km" or "mi".
+	Unit  string `json:"unit"`
+	Stale bool   `json:"stale,omitempty"`
+}
+
+// visibilityHandler handles incoming requests to the "get_visibility" tool.
+func visibilityHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[visibilityHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := normalizeUnit(mcp.ParseString(request, "unit", ""))
+	if err != nil {
+		return nil, err
+	}
+	imperial := unit == "imperial"
+
+	var reading visibilityReading
+	backend, err := fetchMetricReading(ctx, query, "/visibility", nil, "visibility", &reading)
+	if err != nil {
+		return nil, err
+	}
+
+	result := visibilityResult{Location: location, Unit: "km", Stale: backend.Stale}
+	if imperial {
+		result.Unit = "mi"
+	}
+	if reading.Visibility == nil {
+		text := fmt.Sprintf("Visibility for %s: no visibility data is available for this location", query.Label)
+		return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+	}
+	if *reading.Visibility < 0 {
+		return nil, invalidResponsef("backend returned a negative visibility of %g km", *reading.Visibility)
+	}
+	value := *reading.Visibility
+	if imperial {
+		value /= kmPerMile
+		if format.Precision < 0 {
+			format.Precision = milesPrecision
+		}
+	}
+	value = format.round(value)
+	result.Available = true
+	result.Visibility = &value
+	text := fmt.Sprintf("Visibility for %s: %s %s", query.Label, format.format(value), result.Unit)
+	return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+}
@@ -0,0 +1,59 @@
+// warmup.go
+// Cache warm-up for frequently queried locations.
+//
+// CACHE_WARM_LOCATIONS lists locations whose current temperature is fetched
+// at startup and then again every CACHE_WARM_INTERVAL, before the cached
+// entry expires, so requests for them (in the default metric unit) are always
+// answered from the cache. Warm-up failures are logged and retried on the
+// next round; they never affect the server itself.
+
+package main
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+)
+
+// startCacheWarmer starts the background warm-up of c.WarmLocations, if any.
+func startCacheWarmer(c *config) {
+	if len(c.WarmLocations) == 0 {
+		return
+	}
+	if c.CacheTTL <= 0 {
+		log.Printf("[cacheWarmer] WARNING: CACHE_WARM_LOCATIONS is set but caching is disabled; not warming")
+		return
+	}
+	log.Printf("[cacheWarmer] Warming %d location(s) every %s", len(c.WarmLocations), c.WarmInterval)
+	go func() {
+		ticker := time.NewTicker(c.WarmInterval)
+		defer ticker.Stop()
+		for {
+			warmCache(context.Background(), c.WarmLocations)
+			<-ticker.C
+		}
+	}()
+}
+
+// warmCache refreshes the cached temperature of each location.
+func warmCache(ctx context.Context, locations []string) {
+	for _, location := range locations {
+		query, err := resolveLocation(ctx, location)
+		if err != nil {
+			log.Printf("[cacheWarmer] WARNING: cannot warm %q: %v", location, err)
+			continue
+		}
+		// The same parameters a default get_temperature call sends, so the
+		// entry is the one it looks up.
+		params := url.Values{}
+		query.setParams(params)
+		params.Set("units", backendUnit("metric"))
+		setLangParam(params, cfg.DefaultLang)
+		if err := refreshBackend(ctx, "/temperature", params); err != nil {
+			log.Printf("[cacheWarmer] WARNING: failed to warm %q: %v", location, err)
+			continue
+		}
+		log.Printf("[cacheWarmer] Warmed %q", logCoordinates(location))
+	}
+}
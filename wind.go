@@ -0,0 +1,188 @@
+// wind.go
+// The "get_wind" tool.
+//
+// get_wind returns the wind speed, gusts and direction for a location,
+// queried from the backend's /wind endpoint. The backend reports metres per
+// second; other speed units are converted locally. The speed unit is chosen
+// with "speed_unit", independently of the unit system, so sailors can have
+// knots whatever they use for temperatures; without it, metric reports km/h
+// and imperial mph.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// windSpeedUnit is a unit get_wind can report speeds in.
+type windSpeedUnit struct {
+	// Label is how the unit is written, e.g. "km/h".
+	Label string
+	// PerMS is the number of units in one metre per second.
+	PerMS float64
+}
+
+// windSpeedUnits are the supported speed units, keyed by their normalized
+// names and aliases.
+var windSpeedUnits = map[string]windSpeedUnit{
+	"m/s":   {"m/s", 1},
+	"ms":    {"m/s", 1},
+	"km/h":  {"km/h", 3.6},
+	"kmh":   {"km/h", 3.6},
+	"kph":   {"km/h", 3.6},
+	"mph":   {"mph", 3600 / 1609.344},
+	"knots": {"kn", 3600 / 1852.0},
+	"knot":  {"kn", 3600 / 1852.0},
+	"kn":    {"kn", 3600 / 1852.0},
+	"kt":    {"kn", 3600 / 1852.0},
+}
+
+// windPrecision is the default number of decimals for converted speeds.
+const windPrecision = 1
+
+// compassPoints are the 16 compass directions, clockwise from north.
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// newWindTool defines the "get_wind" tool.
+func newWindTool() mcp.Tool {
+	return mcp.NewTool("get_wind",
+		readOnlyAnnotation("Wind", true),
+		mcp.WithDescription("Get the wind speed, gusts and direction for a given location"),
+		mcp.WithString("location",
+			mcp.Description("Name of the location to get the wind for (defaults to the server's DEFAULT_LOCATION, if set)"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit system, for the default speed unit: metric for km/h (the default) or imperial for mph"),
+		),
+		mcp.WithString("speed_unit",
+			mcp.Description("Speed unit, overriding the unit system's: m/s, km/h, mph or knots"),
+			mcp.Enum("m/s", "km/h", "mph", "knots"),
+		),
+		formatOptions("the value as reported for m/s and 1 otherwise"),
+		freshOption(),
+		latencyOption(),
+		prettyOption(),
+		envelopeOption(),
+	)
+}
+
+// windReading is the backend's /wind response.
+type windReading struct {
+	Location string `json:"location"`
+	// Speed and Gust are in metres per second.
+	Speed *float64 `json:"speed"`
+	Gust  *float64 `json:"gust"`
+	// Direction is where the wind blows from, in degrees clockwise from north.
+	Direction *float64 `json:"direction"`
+}
+
+// windResult is the structured result of get_wind.
+type windResult struct {
+	Location  string   `json:"location"`
+	Available bool     `json:"available"`
+	Speed     *float64 `json:"speed,omitempty"`
+	Gust      *float64 `json:"gust,omitempty"`
+	// Direction is in degrees, and Compass the matching compass point.
+	Direction *float64 `json:"direction,omitempty"`
+	Compass   string   `json:"compass,omitempty"`
+	// SpeedUnit is the unit of Speed and Gust: "m/s", "km/h", "mph" or "kn".
+	SpeedUnit string `json:"speed_unit"`
+	Stale     bool   `json:"stale,omitempty"`
+}
+
+// speedUnitArg reads the "speed_unit" argument, defaulting to km/h for the
+// metric (and Kelvin) unit system and mph for imperial.
+func speedUnitArg(request mcp.CallToolRequest, unit string) (windSpeedUnit, error) {
+	name := strings.ToLower(strings.TrimSpace(mcp.ParseString(request, "speed_unit", "")))
+	if name == "" {
+		if unit == "imperial" {
+			return windSpeedUnits["mph"], nil
+		}
+		return windSpeedUnits["km/h"], nil
+	}
+	speedUnit, ok := windSpeedUnits[name]
+	if !ok {
+		return windSpeedUnit{}, invalidArgumentf("speed_unit must be m/s, km/h, mph or knots, got %q", name)
+	}
+	return speedUnit, nil
+}
+
+// compassPoint returns the compass point of a direction in degrees.
+func compassPoint(degrees float64) string {
+	sector := int(math.Round(math.Mod(degrees, 360)/22.5)) % len(compassPoints)
+	return compassPoints[sector]
+}
+
+// windHandler handles incoming requests to the "get_wind" tool.
+func windHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	logf(ctx, "[windHandler] Received Params: %+v", request.Params.Arguments)
+
+	location, err := locationArg(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	format, err := numberFormatArg(request)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := normalizeUnit(mcp.ParseString(request, "unit", ""))
+	if err != nil {
+		return nil, err
+	}
+	speedUnit, err := speedUnitArg(request, unit)
+	if err != nil {
+		return nil, err
+	}
+
+	var reading windReading
+	backend, err := fetchMetricReading(ctx, query, "/wind", nil, "wind", &reading)
+	if err != nil {
+		return nil, err
+	}
+
+	result := windResult{Location: location, SpeedUnit: speedUnit.Label, Stale: backend.Stale}
+	if reading.Speed == nil {
+		text := fmt.Sprintf("Wind for %s: no wind data is available for this location", query.Label)
+		return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+	}
+	if speedUnit.PerMS != 1 && format.Precision < 0 {
+		format.Precision = windPrecision
+	}
+	// speed validates a backend speed in m/s and converts it to speedUnit.
+	speed := func(name string, ms float64) (*float64, error) {
+		if ms < 0 {
+			return nil, invalidResponsef("backend returned a negative wind %s of %g m/s", name, ms)
+		}
+		value := format.round(ms * speedUnit.PerMS)
+		return &value, nil
+	}
+	if result.Speed, err = speed("speed", *reading.Speed); err != nil {
+		return nil, err
+	}
+	result.Available = true
+	text := fmt.Sprintf("Wind for %s: %s %s", query.Label, format.format(*result.Speed), speedUnit.Label)
+	if d := reading.Direction; d != nil {
+		if *d < 0 || *d > 360 {
+			return nil, invalidResponsef("backend returned an invalid wind direction of %g degrees", *d)
+		}
+		direction := numberFormat{Precision: 0}.round(*d)
+		result.Direction, result.Compass = &direction, compassPoint(*d)
+		text += fmt.Sprintf(" from the %s (%s°)", result.Compass, numberFormat{Precision: 0}.format(direction))
+	}
+	if g := reading.Gust; g != nil {
+		if result.Gust, err = speed("gust", *g); err != nil {
+			return nil, err
+		}
+		text += fmt.Sprintf(", gusting to %s %s", format.format(*result.Gust), speedUnit.Label)
+	}
+	return newStructuredResult(text+backend.staleNote(), result, prettyArg(request))
+}